@@ -0,0 +1,12 @@
+package commands
+
+import (
+	"icepeak/core/console"
+)
+
+// Register registers the application's console commands
+func Register(app *console.Application) error {
+	// Add application commands here, e.g.:
+	// return app.Add(console.NewCommand("users:prune {--days=30}", "Prune inactive users", PruneUsers))
+	return app.Add()
+}
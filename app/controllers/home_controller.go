@@ -8,9 +8,10 @@ import (
 
 // HomeController handles requests to the home page
 func HomeController(viewRoot string) http.HandlerFunc {
+	// Load the home view once; `icepeak serve --watch` restarts the app when views change
+	tmpl, err := template.ParseFiles(filepath.Join(viewRoot, "welcome/index.html"))
+
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Load and render the home view
-		tmpl, err := template.ParseFiles(filepath.Join(viewRoot, "welcome/index.html"))
 		if err != nil {
 			http.Error(w, "Error loading template", http.StatusInternalServerError)
 			return
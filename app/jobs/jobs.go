@@ -0,0 +1,12 @@
+package jobs
+
+import (
+	"icepeak/core/queue"
+)
+
+// Register registers the application's queued job types
+func Register(q *queue.Queue) {
+	// Register each job type so workers can run it, e.g.:
+	// q.Register(SendWelcomeMail{})
+	q.Register()
+}
@@ -0,0 +1,29 @@
+// Package bootstrap holds application initialization and bootstrap logic.
+package bootstrap
+
+import (
+	"icepeak/app/jobs"
+	"icepeak/core"
+	"icepeak/routes"
+)
+
+// NewKernel creates the application kernel with its middleware and routes registered
+func NewKernel(options ...core.KernelOption) *core.Kernel {
+	// Create a new kernel instance
+	kernel := core.NewKernel(options...)
+
+	// Register middleware globally; maintenance mode runs first
+	kernel.RegisterMiddleware(kernel.MaintenanceMiddleware())
+	kernel.RegisterMiddleware(core.RequestLoggingMiddleware(kernel.Logger()))
+	kernel.RegisterMiddleware(kernel.CORSMiddleware()) // Configured in config/cors.yaml
+
+	// Register routes with selective middleware
+	routes.RegisterWebRoutes(kernel.Router, kernel.ConfigString("VIEW_ROOT", "./resources/views/"), core.InputValidationMiddleware([]string{"name", "email"}))
+	routes.RegisterAPIRoutes(kernel.Router)
+
+	// Register scheduled tasks and queued jobs
+	routes.RegisterSchedule(kernel.Schedule())
+	jobs.Register(kernel.Queue())
+
+	return kernel
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"icepeak/app/commands"
+	"icepeak/bootstrap"
+	"icepeak/core"
+	"icepeak/core/console"
+)
+
+// version is the version reported by the icepeak binary
+const version = "0.1.0"
+
+func main() {
+	app := console.NewApplication("icepeak", version, func() *core.Kernel {
+		return bootstrap.NewKernel()
+	})
+
+	// Register the framework commands followed by the application commands
+	registrars := []func(*console.Application) error{
+		console.RegisterBuiltinCommands,
+		console.RegisterGeneratorCommands,
+		console.RegisterNewCommand,
+		console.RegisterMaintenanceCommands,
+		console.RegisterScheduleCommands,
+		console.RegisterQueueCommands,
+		console.RegisterContainerCommands,
+		commands.Register,
+	}
+	for _, register := range registrars {
+		if err := register(app); err != nil {
+			fmt.Fprintf(os.Stderr, "Error registering commands: %v\n", err)
+			os.Exit(console.ExitFailure)
+		}
+	}
+
+	os.Exit(app.Run(os.Args[1:]))
+}
@@ -0,0 +1,312 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"icepeak/core/watcher"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigChange describes the keys that changed in a configuration reload.
+type ConfigChange struct {
+	Keys []string
+}
+
+// Has reports whether any of the given keys changed.
+func (c ConfigChange) Has(keys ...string) bool {
+	for _, changed := range c.Keys {
+		for _, key := range keys {
+			if changed == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WithConfigReload sets how often the configuration files are checked for changes while
+// the server runs. Zero disables reloading.
+func WithConfigReload(interval time.Duration) KernelOption {
+	return func(k *Kernel) {
+		k.reloadInterval = interval
+	}
+}
+
+// loadEnvironment loads environment variables from the .env file. They stay with the kernel
+// rather than being set in the process environment, so kernels loading different files
+// don't see each other's values.
+func (k *Kernel) loadEnvironment() {
+	dotenv, err := readEnvFile(k.envFile)
+	if err != nil {
+		fmt.Printf("Error loading %s file\n", k.envFile)
+	}
+	k.dotenv = dotenv
+}
+
+// loadConfiguration loads the configuration from the YAML files in the config directory
+func (k *Kernel) loadConfiguration() {
+	config, sections, err := readConfiguration(k.configDir)
+	if err != nil {
+		fmt.Printf("Error loading configuration: %v\n", err)
+	}
+	for key, value := range config {
+		k.Config[key] = value
+	}
+	k.configSections = sections
+}
+
+// validateConfiguration validates the configuration, warning in development and
+// keeping the error in other environments so the server refuses to start.
+func (k *Kernel) validateConfiguration() {
+	err := k.ValidateConfig()
+	if err == nil {
+		return
+	}
+	if k.Environment() == "development" {
+		fmt.Printf("Warning: %v\n", err)
+		return
+	}
+	fmt.Printf("Error: %v\n", err)
+	k.configErr = err
+}
+
+// ConfigError returns the configuration error that prevents the server from starting, if any.
+func (k *Kernel) ConfigError() error {
+	return k.configErr
+}
+
+// ConfigValue returns a configuration value, falling back to the environment variable of the same name.
+func (k *Kernel) ConfigValue(key string) (interface{}, bool) {
+	k.configMu.RLock()
+	defer k.configMu.RUnlock()
+	return k.lookup(k.Config, k.dotenv, key)
+}
+
+// ConfigSnapshot returns a copy of the YAML configuration, safe to read while it reloads.
+func (k *Kernel) ConfigSnapshot() map[string]interface{} {
+	k.configMu.RLock()
+	defer k.configMu.RUnlock()
+	snapshot := make(map[string]interface{}, len(k.Config))
+	for key, value := range k.Config {
+		snapshot[key] = value
+	}
+	return snapshot
+}
+
+// ConfigString returns a configuration value as a string, or fallback if it is not set.
+func (k *Kernel) ConfigString(key, fallback string) string {
+	value, ok := k.ConfigValue(key)
+	if !ok {
+		return fallback
+	}
+	return fmt.Sprint(value)
+}
+
+// ConfigStrings returns a list or comma-separated configuration value, or fallback if it is not set.
+func (k *Kernel) ConfigStrings(key string, fallback []string) []string {
+	value, ok := k.ConfigValue(key)
+	if !ok {
+		return fallback
+	}
+	list, err := coerce(ConfigList, value)
+	if err != nil {
+		return fallback
+	}
+
+	values := []string{}
+	for _, item := range list.([]interface{}) {
+		values = append(values, fmt.Sprint(item))
+	}
+	return values
+}
+
+// lookup finds a key in the YAML configuration, then the process environment, then the .env
+// file, so variables set in the process take precedence over the file.
+func (k *Kernel) lookup(config map[string]interface{}, dotenv map[string]string, key string) (interface{}, bool) {
+	if value, ok := config[key]; ok {
+		return value, true
+	}
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true
+	}
+	if value, ok := dotenv[key]; ok {
+		return value, true
+	}
+	return nil, false
+}
+
+// env returns an environment variable from the process environment or the .env file,
+// ignoring the YAML configuration.
+func (k *Kernel) env(key string) string {
+	k.configMu.RLock()
+	defer k.configMu.RUnlock()
+	value, _ := k.lookup(nil, k.dotenv, key)
+	env, _ := value.(string)
+	return env
+}
+
+// OnConfigChange subscribes to configuration reloads. Listeners run after the new
+// configuration is in place, in subscription order.
+func (k *Kernel) OnConfigChange(listener func(change ConfigChange)) {
+	k.configMu.Lock()
+	defer k.configMu.Unlock()
+	k.configListeners = append(k.configListeners, listener)
+}
+
+// ReloadConfig re-reads the YAML configuration and the .env file. The new configuration
+// replaces the current one only if it passes validation; otherwise the previous one is kept.
+func (k *Kernel) ReloadConfig() error {
+	config, sections, err := readConfiguration(k.configDir)
+	if err != nil {
+		return err
+	}
+	dotenv, err := readEnvFile(k.envFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	err = k.validateWith(func(key string) (interface{}, bool) {
+		return k.lookup(config, dotenv, key)
+	}, sections)
+	if err != nil {
+		return err
+	}
+
+	k.configMu.Lock()
+	change := ConfigChange{Keys: changedKeys(k.Config, config, k.dotenv, dotenv)}
+	k.Config = config
+	k.configSections = sections
+	k.dotenv = dotenv
+	listeners := append([]func(ConfigChange){}, k.configListeners...)
+	k.configMu.Unlock()
+
+	if len(change.Keys) > 0 {
+		for _, listener := range listeners {
+			listener(change)
+		}
+	}
+	return nil
+}
+
+// WatchConfig reloads the configuration whenever the config directory or .env file changes,
+// until the returned function is called, which waits for a reload in progress.
+func (k *Kernel) WatchConfig(interval time.Duration) (stop func()) {
+	w := watcher.New(interval, k.configDir, k.envFile)
+	done, finished := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(finished)
+		w.Run(done, k.reloadChangedConfig)
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// reloadChangedConfig reloads the configuration after the watcher saw files change.
+func (k *Kernel) reloadChangedConfig(changed []string) {
+	logger := k.Logger()
+	if err := k.ReloadConfig(); err != nil {
+		if logger != nil {
+			logger.Error(fmt.Sprintf("Keeping previous configuration: %v", err))
+		}
+		return
+	}
+	if logger != nil {
+		logger.Info(fmt.Sprintf("Configuration reloaded after changes to %s", strings.Join(changed, ", ")))
+	}
+}
+
+// registerConfigListeners keeps framework services in sync with reloaded configuration.
+func (k *Kernel) registerConfigListeners() {
+	k.OnConfigChange(func(change ConfigChange) {
+		if !change.Has("LOG_LEVEL") {
+			return
+		}
+		if logger, ok := k.Logger().(interface{ SetLevel(level string) }); ok {
+			logger.SetLevel(k.ConfigString("LOG_LEVEL", "DEBUG"))
+		}
+	})
+}
+
+// readConfiguration reads and merges the YAML files in a directory in name order. It also
+// returns the sections, the file names without .yaml, that set each key.
+func readConfiguration(dir string) (map[string]interface{}, map[string][]string, error) {
+	config := make(map[string]interface{})
+	sections := make(map[string][]string)
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return config, sections, err
+	}
+	if len(files) == 0 {
+		return config, sections, fmt.Errorf("no YAML files in %s", dir)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return config, sections, err
+		}
+		values := make(map[string]interface{})
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return config, sections, fmt.Errorf("parsing %s: %w", file, err)
+		}
+		section := strings.TrimSuffix(filepath.Base(file), ".yaml")
+		for key, value := range values {
+			config[key] = value
+			sections[key] = append(sections[key], section)
+		}
+	}
+	return config, sections, nil
+}
+
+// readEnvFile reads a .env file, returning no values alongside any error.
+func readEnvFile(path string) (map[string]string, error) {
+	values, err := godotenv.Read(path)
+	if err != nil {
+		return map[string]string{}, err
+	}
+	return values, nil
+}
+
+// changedKeys returns the keys whose values differ between two configurations.
+func changedKeys(oldConfig, newConfig map[string]interface{}, oldEnv, newEnv map[string]string) []string {
+	keys := map[string]bool{}
+	for key, value := range newConfig {
+		if old, ok := oldConfig[key]; !ok || !reflect.DeepEqual(old, value) {
+			keys[key] = true
+		}
+	}
+	for key := range oldConfig {
+		if _, ok := newConfig[key]; !ok {
+			keys[key] = true
+		}
+	}
+	for key, value := range newEnv {
+		if old, ok := oldEnv[key]; !ok || old != value {
+			keys[key] = true
+		}
+	}
+	for key := range oldEnv {
+		if _, ok := newEnv[key]; !ok {
+			keys[key] = true
+		}
+	}
+
+	changed := make([]string, 0, len(keys))
+	for key := range keys {
+		changed = append(changed, key)
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,286 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"icepeak/core/features"
+)
+
+// ConfigType is the expected type of a configuration value.
+type ConfigType string
+
+// Configuration value types. Values read from the environment are parsed into these types.
+const (
+	ConfigString   ConfigType = "string"
+	ConfigInt      ConfigType = "int"
+	ConfigFloat    ConfigType = "float"
+	ConfigBool     ConfigType = "bool"
+	ConfigDuration ConfigType = "duration"
+	ConfigList     ConfigType = "list"
+	ConfigMap      ConfigType = "map"
+)
+
+// ConfigField declares the constraints of one configuration key.
+type ConfigField struct {
+	Key      string
+	Type     ConfigType
+	Required bool
+	Enum     []string                      // Allowed values, compared case-sensitively
+	Min      *float64                      // Lower bound of numbers, or of the length of strings and lists
+	Max      *float64                      // Upper bound of numbers, or of the length of strings and lists
+	Check    func(value interface{}) error // Custom check run after the others pass
+}
+
+// ConfigSchema declares the keys of a configuration section, e.g. "view" for config/view.yaml.
+// Keys set in YAML must be set in the file of their section, and only there; they may
+// also come from the environment.
+type ConfigSchema struct {
+	Section string
+	Fields  []ConfigField
+}
+
+// ConfigError is a single configuration problem.
+type ConfigError struct {
+	Section string
+	Key     string
+	Message string
+}
+
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("%s.%s: %s", e.Section, e.Key, e.Message)
+}
+
+// ConfigErrors collects every configuration problem into one report.
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	lines := []string{fmt.Sprintf("configuration is invalid (%d errors):", len(e))}
+	for _, err := range e {
+		lines = append(lines, "  - "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Bound returns a pointer to a bound for ConfigField.Min and ConfigField.Max.
+func Bound(value float64) *float64 {
+	return &value
+}
+
+// WithConfigSchema registers a configuration schema validated when the kernel is created.
+func WithConfigSchema(schema ConfigSchema) KernelOption {
+	return func(k *Kernel) {
+		k.schemas = append(k.schemas, schema)
+	}
+}
+
+// RegisterConfigSchema registers a configuration schema checked by ValidateConfig.
+func (k *Kernel) RegisterConfigSchema(schema ConfigSchema) {
+	k.schemas = append(k.schemas, schema)
+}
+
+// registerDefaultConfigSchemas registers the schemas of the configuration the framework reads.
+func (k *Kernel) registerDefaultConfigSchemas() {
+	k.schemas = append([]ConfigSchema{
+		{Section: "app", Fields: []ConfigField{
+			{Key: "LOG_LEVEL", Type: ConfigString, Enum: LogLevels},
+		}},
+		{Section: "cors", Fields: []ConfigField{
+			{Key: "CORS_ALLOWED_ORIGINS", Type: ConfigList},
+			{Key: "CORS_ALLOWED_METHODS", Type: ConfigList},
+			{Key: "CORS_ALLOWED_HEADERS", Type: ConfigList},
+			{Key: "CORS_ALLOW_CREDENTIALS", Type: ConfigBool},
+		}},
+		{Section: "features", Fields: []ConfigField{
+			{Key: "FEATURES", Type: ConfigMap, Check: func(value interface{}) error {
+				_, err := features.ParseFlags(value)
+				return err
+			}},
+			{Key: "FEATURES_DRIVER", Type: ConfigString, Enum: []string{"config", "file", "sql"}},
+			{Key: "FEATURES_PATH", Type: ConfigString, Min: Bound(1)},
+		}},
+		{Section: "queue", Fields: []ConfigField{
+			{Key: "QUEUE_DRIVER", Type: ConfigString, Enum: []string{"file", "memory", "sql"}},
+			{Key: "QUEUE_PATH", Type: ConfigString, Min: Bound(1)},
+			{Key: "QUEUE_SQL_DIALECT", Type: ConfigString, Enum: []string{"sqlite", "mysql", "postgres"}},
+		}},
+		{Section: "rate_limit", Fields: []ConfigField{
+			{Key: "RATE_LIMIT_INTERVAL", Type: ConfigDuration, Min: Bound(0.001)},
+			{Key: "RATE_LIMIT_BURST", Type: ConfigInt, Min: Bound(1)},
+		}},
+		{Section: "schedule", Fields: []ConfigField{
+			{Key: "SCHEDULE_TIMEZONE", Type: ConfigString, Check: validateTimezone},
+		}},
+		{Section: "view", Fields: []ConfigField{
+			{Key: "VIEW_ROOT", Type: ConfigString, Required: true, Check: func(value interface{}) error {
+				if info, err := os.Stat(value.(string)); err != nil || !info.IsDir() {
+					return fmt.Errorf("directory %q does not exist", value)
+				}
+				return nil
+			}},
+		}},
+	}, k.schemas...)
+}
+
+// ValidateConfig checks the configuration against every registered schema and returns all problems at once.
+func (k *Kernel) ValidateConfig() error {
+	k.configMu.RLock()
+	sections := k.configSections
+	k.configMu.RUnlock()
+	return k.validateWith(k.ConfigValue, sections)
+}
+
+// validateWith checks the values returned by lookup against every registered schema, and
+// that the YAML sections setting each key are those of its schema.
+func (k *Kernel) validateWith(lookup func(key string) (interface{}, bool), sections map[string][]string) error {
+	errs := ConfigErrors{}
+	for _, schema := range k.schemas {
+		for _, field := range schema.Fields {
+			if files := sections[field.Key]; len(files) > 1 {
+				errs = append(errs, ConfigError{schema.Section, field.Key, fmt.Sprintf("set in several files (%s.yaml), keep it in %s.yaml", strings.Join(files, ".yaml, "), schema.Section)})
+				continue
+			} else if len(files) == 1 && files[0] != schema.Section {
+				errs = append(errs, ConfigError{schema.Section, field.Key, fmt.Sprintf("set in %s.yaml, move it to %s.yaml", files[0], schema.Section)})
+				continue
+			}
+
+			value, ok := lookup(field.Key)
+			if !ok {
+				if field.Required {
+					errs = append(errs, ConfigError{schema.Section, field.Key, "required key is missing"})
+				}
+				continue
+			}
+			if err := validateField(field, value); err != nil {
+				errs = append(errs, ConfigError{schema.Section, field.Key, err.Error()})
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Section < errs[j].Section
+	})
+	return errs
+}
+
+// validateField checks a value against the type, enum, range and custom check of a field.
+func validateField(field ConfigField, value interface{}) error {
+	value, err := coerce(field.Type, value)
+	if err != nil {
+		return err
+	}
+
+	if len(field.Enum) > 0 {
+		text := fmt.Sprint(value)
+		allowed := false
+		for _, option := range field.Enum {
+			if text == option {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%q is not one of %s", text, strings.Join(field.Enum, ", "))
+		}
+	}
+
+	var measure float64
+	var unit string
+	switch v := value.(type) {
+	case int:
+		measure = float64(v)
+	case float64:
+		measure = v
+	case time.Duration:
+		measure, unit = v.Seconds(), " seconds"
+	case string:
+		measure, unit = float64(len(v)), " characters"
+	case []interface{}:
+		measure, unit = float64(len(v)), " items"
+	}
+	if field.Min != nil && measure < *field.Min {
+		return fmt.Errorf("%v is below the minimum of %v%s", value, *field.Min, unit)
+	}
+	if field.Max != nil && measure > *field.Max {
+		return fmt.Errorf("%v is above the maximum of %v%s", value, *field.Max, unit)
+	}
+
+	if field.Check != nil {
+		return field.Check(value)
+	}
+	return nil
+}
+
+// coerce converts a YAML or environment value to the expected type.
+func coerce(expected ConfigType, value interface{}) (interface{}, error) {
+	text, isText := value.(string)
+	mismatch := fmt.Errorf("expected %s, got %T %v", expected, value, value)
+
+	switch expected {
+	case ConfigString, "":
+		if !isText {
+			return nil, mismatch
+		}
+		return text, nil
+	case ConfigInt:
+		if n, ok := value.(int); ok {
+			return n, nil
+		}
+		if n, err := strconv.Atoi(text); isText && err == nil {
+			return n, nil
+		}
+	case ConfigFloat:
+		switch n := value.(type) {
+		case int:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+		if n, err := strconv.ParseFloat(text, 64); isText && err == nil {
+			return n, nil
+		}
+	case ConfigBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		if b, err := strconv.ParseBool(text); isText && err == nil {
+			return b, nil
+		}
+	case ConfigDuration:
+		if d, err := time.ParseDuration(text); isText && err == nil {
+			return d, nil
+		}
+	case ConfigList:
+		if list, ok := value.([]interface{}); ok {
+			return list, nil
+		}
+		if isText {
+			list := []interface{}{}
+			for _, item := range strings.Split(text, ",") {
+				list = append(list, strings.TrimSpace(item))
+			}
+			return list, nil
+		}
+	case ConfigMap:
+		switch m := value.(type) {
+		case map[string]interface{}:
+			return m, nil
+		case map[interface{}]interface{}:
+			converted := make(map[string]interface{}, len(m))
+			for key, item := range m {
+				converted[fmt.Sprint(key)] = item
+			}
+			return converted, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown type %s", expected)
+	}
+	return nil, mismatch
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// discardLogger drops every message.
+type discardLogger struct{}
+
+func (discardLogger) Debug(msg string)                            {}
+func (discardLogger) Info(msg string)                             {}
+func (discardLogger) Warn(msg string)                             {}
+func (discardLogger) Error(msg string)                            {}
+func (discardLogger) LogRequest(r *http.Request, start time.Time) {}
+
+// configKernel creates a kernel reading the given YAML files, by name.
+func configKernel(t *testing.T, files map[string]string, options ...KernelOption) *Kernel {
+	dir := t.TempDir()
+	files["view.yaml"] += "\nVIEW_ROOT: " + dir + "\n"
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	options = append([]KernelOption{WithConfigDir(dir), WithEnvFile(filepath.Join(dir, ".env")), WithLogger(discardLogger{}), WithScheduler(false)}, options...)
+	return NewKernel(options...)
+}
+
+func TestConfigKeysBelongToTheFileOfTheirSection(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	k := configKernel(t, map[string]string{
+		"app.yaml":      "LOG_LEVEL: INFO\nQUEUE_DRIVER: file\n",
+		"queue.yaml":    "QUEUE_PATH: storage/queue\n",
+		"features.yaml": "FEATURES_PATH: flags.json\n",
+		"extra.yaml":    "FEATURES_PATH: other.json\nAPP_NAME: blog\n",
+	})
+
+	err := k.ValidateConfig()
+	var errs ConfigErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("got %v, want the misplaced and the duplicated key", err)
+	}
+	for _, want := range []string{
+		"features.FEATURES_PATH: set in several files (extra.yaml, features.yaml), keep it in features.yaml",
+		"queue.QUEUE_DRIVER: set in app.yaml, move it to queue.yaml",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%v doesn't report %q", err, want)
+		}
+	}
+}
+
+func TestConfigKeysMayComeFromTheEnvironment(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("QUEUE_DRIVER", "memory")
+	k := configKernel(t, map[string]string{"app.yaml": "LOG_LEVEL: INFO\n"})
+
+	if err := k.ValidateConfig(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBootRefusesInvalidConfiguration(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	k := configKernel(t, map[string]string{"app.yaml": "LOG_LEVEL: LOUD\n"})
+
+	err := k.Boot()
+	if err == nil || !strings.Contains(err.Error(), "app.LOG_LEVEL") {
+		t.Fatalf("booting returned %v, want the invalid LOG_LEVEL", err)
+	}
+	if err := k.Boot(); err == nil {
+		t.Fatal("booting again succeeded with the same configuration")
+	}
+}
+
+func TestBootWarnsAboutInvalidConfigurationInDevelopment(t *testing.T) {
+	t.Setenv("APP_ENV", "development")
+	k := configKernel(t, map[string]string{"app.yaml": "LOG_LEVEL: LOUD\n"})
+
+	if err := k.Boot(); err != nil {
+		t.Fatalf("booting in development returned %v, want a warning only", err)
+	}
+}
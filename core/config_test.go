@@ -0,0 +1,56 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKernelsKeepTheirOwnEnvFiles(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	first := configKernel(t, map[string]string{".env": "MAIL_FROM=first@example.com\nMAIL_NAME=First\n"})
+	second := configKernel(t, map[string]string{".env": "MAIL_FROM=second@example.com\n"})
+
+	if _, set := os.LookupEnv("MAIL_FROM"); set {
+		t.Fatal("loading a .env file set MAIL_FROM in the process environment")
+	}
+	if got := first.ConfigString("MAIL_FROM", ""); got != "first@example.com" {
+		t.Fatalf("first kernel got MAIL_FROM %q, want its own", got)
+	}
+	if got := second.ConfigString("MAIL_FROM", ""); got != "second@example.com" {
+		t.Fatalf("second kernel got MAIL_FROM %q, want its own", got)
+	}
+	if got := second.ConfigString("MAIL_NAME", "none"); got != "none" {
+		t.Fatalf("second kernel got MAIL_NAME %q from the first kernel's file", got)
+	}
+
+	// Reloading one kernel leaves the values of the other alone
+	if err := os.WriteFile(filepath.Join(first.configDir, ".env"), []byte("MAIL_NAME=First\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if got := first.ConfigString("MAIL_FROM", "none"); got != "none" {
+		t.Fatalf("first kernel got MAIL_FROM %q after removing it from its file", got)
+	}
+	if got := second.ConfigString("MAIL_FROM", ""); got != "second@example.com" {
+		t.Fatalf("second kernel got MAIL_FROM %q after the first reloaded, want its own", got)
+	}
+
+	// The process environment takes precedence over the files
+	t.Setenv("MAIL_FROM", "process@example.com")
+	if got := second.ConfigString("MAIL_FROM", ""); got != "process@example.com" {
+		t.Fatalf("second kernel got MAIL_FROM %q, want the process environment's", got)
+	}
+}
+
+func TestEnvironmentComesFromTheEnvFile(t *testing.T) {
+	t.Setenv("APP_ENV", "") // Restored after the test
+	os.Unsetenv("APP_ENV")
+	k := configKernel(t, map[string]string{".env": "APP_ENV=staging\n"})
+
+	if got := k.Environment(); got != "staging" {
+		t.Fatalf("environment is %q, want the one of the .env file", got)
+	}
+}
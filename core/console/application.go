@@ -0,0 +1,320 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"icepeak/core"
+)
+
+// Exit codes returned by Application.Run.
+const (
+	ExitSuccess = 0
+	ExitFailure = 1
+	ExitUsage   = 2
+)
+
+// Command is a console command run by the icepeak binary.
+type Command interface {
+	Signature() string
+	Description() string
+	Handle(ctx *Context) error
+}
+
+// ExitError makes a command exit with a specific code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// commandFunc adapts a plain function to the Command interface.
+type commandFunc struct {
+	signature   string
+	description string
+	handler     func(ctx *Context) error
+}
+
+func (c *commandFunc) Signature() string         { return c.signature }
+func (c *commandFunc) Description() string       { return c.description }
+func (c *commandFunc) Handle(ctx *Context) error { return c.handler(ctx) }
+
+// NewCommand creates a command from a signature, a description and a handler.
+func NewCommand(signature, description string, handler func(ctx *Context) error) Command {
+	return &commandFunc{signature: signature, description: description, handler: handler}
+}
+
+// Context is passed to a running command.
+type Context struct {
+	*Input
+	Output *Output
+	app    *Application
+}
+
+// Kernel returns the application kernel, booting it on first use.
+func (c *Context) Kernel() *core.Kernel {
+	return c.app.Kernel()
+}
+
+// Resolve resolves a service from the kernel's service container.
+func (c *Context) Resolve(name string) (interface{}, error) {
+	kernel := c.Kernel()
+	if kernel == nil {
+		return nil, errors.New("no kernel available")
+	}
+	return kernel.Services.Resolve(name)
+}
+
+// Call invokes fn with the command context and the services it asks for, resolved by type
+// from the kernel's service container, returning the error fn returns:
+//
+//	return ctx.Call(func(ctx *console.Context, repo *UserRepo) error { ... })
+func (c *Context) Call(fn interface{}) error {
+	kernel := c.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+	_, err := kernel.Services.Call(fn, c)
+	return err
+}
+
+// registeredCommand pairs a command with its parsed signature.
+type registeredCommand struct {
+	command    Command
+	definition *Definition
+}
+
+// Application is the registry and runner of console commands.
+type Application struct {
+	Name    string
+	Version string
+	Out     io.Writer
+	Err     io.Writer
+
+	commands map[string]*registeredCommand
+	boot     func() *core.Kernel
+	kernel   *core.Kernel
+	once     sync.Once
+}
+
+// NewApplication creates a console application. The boot function creates the
+// kernel the first time a command needs it, so commands that don't need one
+// can run outside an Icepeak project.
+func NewApplication(name, version string, boot func() *core.Kernel) *Application {
+	return &Application{
+		Name:     name,
+		Version:  version,
+		Out:      os.Stdout,
+		Err:      os.Stderr,
+		commands: make(map[string]*registeredCommand),
+		boot:     boot,
+	}
+}
+
+// Kernel returns the application kernel, booting it on first use.
+func (a *Application) Kernel() *core.Kernel {
+	a.once.Do(func() {
+		if a.boot != nil {
+			a.kernel = a.boot()
+		}
+	})
+	return a.kernel
+}
+
+// Add registers commands, replacing any existing command with the same name.
+func (a *Application) Add(commands ...Command) error {
+	for _, command := range commands {
+		definition, err := ParseSignature(command.Signature())
+		if err != nil {
+			return err
+		}
+		a.commands[definition.Name] = &registeredCommand{command: command, definition: definition}
+	}
+	return nil
+}
+
+// Find returns the command registered under a name.
+func (a *Application) Find(name string) (Command, bool) {
+	registered, ok := a.commands[name]
+	if !ok {
+		return nil, false
+	}
+	return registered.command, true
+}
+
+// Run runs the command named by the first argument and returns its exit code.
+func (a *Application) Run(args []string) int {
+	output := &Output{Out: a.Out, Err: a.Err}
+
+	if len(args) == 0 || args[0] == "list" || args[0] == "--help" || args[0] == "-h" {
+		a.writeList(output)
+		return ExitSuccess
+	}
+	if args[0] == "--version" || args[0] == "-V" {
+		output.Line("%s %s", a.Name, a.Version)
+		return ExitSuccess
+	}
+	if args[0] == "help" {
+		if len(args) < 2 {
+			a.writeList(output)
+			return ExitSuccess
+		}
+		registered, ok := a.commands[args[1]]
+		if !ok {
+			output.Error("Command '%s' is not defined.", args[1])
+			return ExitUsage
+		}
+		a.writeHelp(output, registered)
+		return ExitSuccess
+	}
+
+	registered, ok := a.commands[args[0]]
+	if !ok {
+		output.Error("Command '%s' is not defined.", args[0])
+		if suggestions := a.suggest(args[0]); len(suggestions) > 0 {
+			output.Line("Did you mean one of these?\n    %s", strings.Join(suggestions, "\n    "))
+		}
+		return ExitUsage
+	}
+
+	for _, arg := range args[1:] {
+		if arg == "--" {
+			break
+		}
+		if arg == "--help" || arg == "-h" {
+			a.writeHelp(output, registered)
+			return ExitSuccess
+		}
+	}
+
+	input, err := ParseInput(registered.definition, args[1:])
+	if err != nil {
+		output.Error("%v", err)
+		output.Line("Usage: %s %s", a.Name, registered.definition.Usage())
+		return ExitUsage
+	}
+
+	defer a.shutdown(output)
+	err = registered.command.Handle(&Context{Input: input, Output: output, app: a})
+	if err != nil {
+		var exitErr *ExitError
+		if errors.As(err, &exitErr) {
+			if exitErr.Err != nil {
+				output.Error("%v", exitErr.Err)
+			}
+			return exitErr.Code
+		}
+		output.Error("%v", err)
+		return ExitFailure
+	}
+	return ExitSuccess
+}
+
+// shutdown disposes the services of the kernel, if a command created it.
+func (a *Application) shutdown(output *Output) {
+	if a.kernel == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := a.kernel.Services.Shutdown(ctx); err != nil {
+		output.Error("%v", err)
+	}
+}
+
+// suggest returns registered command names sharing a namespace or prefix with name.
+func (a *Application) suggest(name string) []string {
+	namespace := strings.SplitN(name, ":", 2)[0]
+	suggestions := []string{}
+	for candidate := range a.commands {
+		if strings.HasPrefix(candidate, namespace) {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	sort.Strings(suggestions)
+	return suggestions
+}
+
+// writeList writes the available commands grouped by namespace.
+func (a *Application) writeList(output *Output) {
+	output.Line("%s %s\n", a.Name, a.Version)
+	output.Line("Usage:\n  %s <command> [options] [arguments]\n", a.Name)
+	output.Line("Available commands:")
+
+	names := make([]string, 0, len(a.commands))
+	width := 0
+	for name := range a.commands {
+		names = append(names, name)
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ni, nj := strings.Contains(names[i], ":"), strings.Contains(names[j], ":")
+		if ni != nj {
+			return !ni
+		}
+		return names[i] < names[j]
+	})
+
+	namespace := ""
+	for _, name := range names {
+		if i := strings.Index(name, ":"); i >= 0 && name[:i] != namespace {
+			namespace = name[:i]
+			output.Line(" %s", namespace)
+		}
+		output.Line("  %-*s  %s", width, name, a.commands[name].command.Description())
+	}
+}
+
+// writeHelp writes the usage, arguments and options of a command.
+func (a *Application) writeHelp(output *Output, registered *registeredCommand) {
+	definition := registered.definition
+	output.Line("Description:\n  %s\n", registered.command.Description())
+	output.Line("Usage:\n  %s %s", a.Name, definition.Usage())
+
+	if len(definition.Arguments) > 0 {
+		output.Line("\nArguments:")
+		for _, argument := range definition.Arguments {
+			description := argument.Description
+			if argument.Default != "" {
+				description += fmt.Sprintf(" [default: %q]", argument.Default)
+			}
+			output.Line("  %-20s %s", argument.Name, description)
+		}
+	}
+
+	output.Line("\nOptions:")
+	for _, option := range definition.Options {
+		name := "--" + option.Name
+		if option.AcceptValue {
+			name += "=VALUE"
+		}
+		if option.Shortcut != "" {
+			name = "-" + option.Shortcut + ", " + name
+		}
+		description := option.Description
+		if option.Default != "" {
+			description += fmt.Sprintf(" [default: %q]", option.Default)
+		}
+		output.Line("  %-20s %s", name, description)
+	}
+	output.Line("  %-20s %s", "-h, --help", "Display help for the command")
+}
@@ -0,0 +1,124 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"icepeak/core"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RegisterBuiltinCommands registers the commands every Icepeak application ships with.
+func RegisterBuiltinCommands(app *Application) error {
+	return app.Add(
+		NewCommand("serve {--host= : The host address to serve the application on} {--port=8080 : The port to serve the application on}"+
+			" {--socket= : Also listen on this Unix domain socket} {--socket-mode=0660 : Permissions of the Unix domain socket}"+
+			" {--watch : Rebuild and restart the application when sources, views or config change}",
+			"Serve the application", serveCommand),
+		NewCommand("routes:list {--method= : Filter the routes by method} {--path= : Filter the routes by path prefix}",
+			"List all registered routes", routesListCommand),
+		NewCommand("config:show {key? : The configuration key to show}",
+			"Display the loaded configuration", configShowCommand),
+		NewCommand("env", "Display the current application environment", envCommand),
+	)
+}
+
+// serveCommand starts the HTTP server of the kernel, or the development server with --watch.
+func serveCommand(ctx *Context) error {
+	address := ctx.Option("host") + ":" + ctx.Option("port")
+	if ctx.HasOption("watch") {
+		return serveWatch(ctx, address)
+	}
+
+	kernel := ctx.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+	if socket := ctx.Option("socket"); socket != "" {
+		mode, err := strconv.ParseUint(ctx.Option("socket-mode"), 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid --socket-mode '%s', expected octal permissions", ctx.Option("socket-mode"))
+		}
+		kernel.Listen(core.Listener{Address: "unix://" + socket, Mode: os.FileMode(mode)})
+	}
+	kernel.StartServer(address)
+	return nil
+}
+
+// routesListCommand lists the routes registered with the kernel router.
+func routesListCommand(ctx *Context) error {
+	kernel := ctx.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+
+	method := strings.ToUpper(ctx.Option("method"))
+	rows := [][]string{}
+	for _, route := range kernel.Router.Routes() {
+		if method != "" && route.Method != method {
+			continue
+		}
+		if !strings.HasPrefix(route.Path, ctx.Option("path")) {
+			continue
+		}
+
+		middleware := append([]string{}, route.MiddlewareNames...)
+		if n := len(route.Middleware); n > 0 {
+			middleware = append([]string{fmt.Sprintf("%d anonymous", n)}, middleware...)
+		}
+		for _, excluded := range route.ExcludedMiddleware {
+			middleware = append(middleware, "!"+excluded)
+		}
+		rows = append(rows, []string{route.Method, route.Path, strings.Join(middleware, ", ")})
+	}
+
+	if len(rows) == 0 {
+		ctx.Output.Info("No routes match the given criteria.")
+		return nil
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i][1] < rows[j][1]
+	})
+	ctx.Output.Table([]string{"Method", "URI", "Middleware"}, rows)
+	return nil
+}
+
+// configShowCommand prints the kernel configuration as YAML.
+func configShowCommand(ctx *Context) error {
+	kernel := ctx.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+
+	config := kernel.ConfigSnapshot()
+	var value interface{} = config
+	if key := ctx.Argument("key"); key != "" {
+		v, ok := config[key]
+		if !ok {
+			return fmt.Errorf("configuration key '%s' not found", key)
+		}
+		value = map[string]interface{}{key: v}
+	}
+
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+	ctx.Output.Line("%s", strings.TrimRight(string(data), "\n"))
+	return nil
+}
+
+// envCommand prints the current application environment.
+func envCommand(ctx *Context) error {
+	kernel := ctx.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+	ctx.Output.Info("The application environment is [%s].", kernel.Environment())
+	return nil
+}
@@ -0,0 +1,65 @@
+package console
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"icepeak/core"
+)
+
+// RegisterContainerCommands registers the container:list command.
+func RegisterContainerCommands(app *Application) error {
+	return app.Add(
+		NewCommand("container:list {--graph : Print the dependencies as a Graphviz DOT graph}",
+			"List the services registered in the container", containerListCommand),
+	)
+}
+
+// containerListCommand lists the services with their lifetime, tags and extenders.
+func containerListCommand(ctx *Context) error {
+	kernel := ctx.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+	if err := kernel.Boot(); err != nil {
+		return err
+	}
+
+	if ctx.HasOption("graph") {
+		return kernel.Services.WriteGraph(ctx.Output.Out)
+	}
+
+	descriptions := kernel.Services.Describe()
+	if len(descriptions) == 0 {
+		ctx.Output.Info("No services are registered.")
+		return nil
+	}
+
+	rows := [][]string{}
+	for _, description := range descriptions {
+		instantiated := "-"
+		switch description.Lifetime {
+		case core.LifetimeSingleton:
+			instantiated = "no"
+			if description.Instantiated {
+				instantiated = "yes"
+			}
+		case core.LifetimeScoped:
+			instantiated = "per scope"
+		}
+		extenders := ""
+		if description.Extenders > 0 {
+			extenders = strconv.Itoa(description.Extenders)
+		}
+		rows = append(rows, []string{
+			description.Service,
+			description.Lifetime.String(),
+			strings.Join(description.Tags, ", "),
+			extenders,
+			instantiated,
+		})
+	}
+	ctx.Output.Table([]string{"Service", "Lifetime", "Tags", "Extenders", "Instantiated"}, rows)
+	return nil
+}
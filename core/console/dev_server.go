@@ -0,0 +1,266 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"icepeak/core"
+	"icepeak/core/watcher"
+)
+
+// devStopTimeout bounds how long a replaced process may take to finish its requests.
+const devStopTimeout = 15 * time.Second
+
+// devWatchExtensions are the files whose changes rebuild and restart the application.
+var devWatchExtensions = []string{".go", ".html", ".yaml", ".yml", ".env"}
+
+// deadlineListener is a listener whose Accept can time out, like *net.TCPListener.
+type deadlineListener interface {
+	net.Listener
+	SetDeadline(t time.Time) error
+}
+
+// devServer rebuilds and restarts the application while keeping its listening socket open.
+type devServer struct {
+	listener deadlineListener
+	file     *os.File // Duplicate of the listener handed to each application process
+	binary   string
+	builds   int
+}
+
+// devProcess is a running application process.
+type devProcess struct {
+	cmd    *exec.Cmd
+	binary string
+	exited chan error
+}
+
+// serveWatch runs the development server on address until interrupted.
+func serveWatch(ctx *Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	tcp, ok := listener.(*net.TCPListener)
+	if !ok {
+		return errors.New("watch mode requires a TCP listener")
+	}
+	file, err := tcp.File()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	server := &devServer{
+		listener: tcp,
+		file:     file,
+		binary:   filepath.Join(os.TempDir(), fmt.Sprintf("icepeak-serve-%d", os.Getpid())),
+	}
+
+	// Coalesce changes so a burst of saves triggers one rebuild
+	changes := make(chan []string, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	w := watcher.New(500*time.Millisecond, ".")
+	w.Extensions = devWatchExtensions
+	go w.Run(stop, func(changed []string) {
+		select {
+		case changes <- changed:
+		default:
+		}
+	})
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ctx.Output.Info("Development server listening on %s, watching for changes.", listener.Addr())
+
+	var process *devProcess
+	var page *errorPage
+	rebuild := true
+	for {
+		if rebuild {
+			rebuild = false
+			next, err := server.start()
+			if err != nil {
+				// Show the build error in the browser until the next change
+				ctx.Output.Error("%v", err)
+				server.stopProcess(process)
+				process = nil
+				page.stop()
+				page = server.serveError("Build failed", err.Error())
+			} else {
+				page.stop()
+				page = nil
+				server.stopProcess(process)
+				process = next
+			}
+		}
+
+		var exited chan error
+		if process != nil {
+			exited = process.exited
+		}
+
+		select {
+		case changed := <-changes:
+			ctx.Output.Info("Change detected in %s, rebuilding.", strings.Join(changed, ", "))
+			rebuild = true
+		case err := <-exited:
+			message := "The application exited."
+			if err != nil {
+				message = fmt.Sprintf("The application exited: %v", err)
+			}
+			ctx.Output.Error("%s", message)
+			os.Remove(process.binary)
+			process = nil
+			page = server.serveError("Application stopped", message+"\n\nSave a file to rebuild.")
+		case <-signals:
+			page.stop()
+			server.stopProcess(process)
+			return nil
+		}
+	}
+}
+
+// start builds the application and starts it on the shared listener.
+func (s *devServer) start() (*devProcess, error) {
+	s.builds++
+	binary := fmt.Sprintf("%s-%d", s.binary, s.builds)
+
+	build := exec.Command("go", "build", "-o", binary, ".")
+	var out bytes.Buffer
+	build.Stdout = &out
+	build.Stderr = &out
+	if err := build.Run(); err != nil {
+		return nil, fmt.Errorf("%v\n\n%s", err, strings.TrimSpace(out.String()))
+	}
+
+	cmd := exec.Command(binary)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{s.file} // File descriptor 3 in the child
+	cmd.Env = append(os.Environ(), core.ListenFDEnv+"=3")
+	if err := cmd.Start(); err != nil {
+		os.Remove(binary)
+		return nil, err
+	}
+
+	process := &devProcess{cmd: cmd, binary: binary, exited: make(chan error, 1)}
+	go func() {
+		process.exited <- cmd.Wait()
+	}()
+	return process, nil
+}
+
+// stopProcess asks a process to shut down gracefully and kills it after devStopTimeout.
+// Connections arriving meanwhile wait in the listen queue for the next process.
+func (s *devServer) stopProcess(process *devProcess) {
+	if process == nil {
+		return
+	}
+	defer os.Remove(process.binary)
+
+	process.cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-process.exited:
+	case <-time.After(devStopTimeout):
+		process.cmd.Process.Kill()
+		<-process.exited
+	}
+}
+
+// errorPage serves an error on the shared listener until stopped.
+type errorPage struct {
+	listener *pausableListener
+	done     chan struct{}
+}
+
+// serveError serves a development error page on the listener while no application process is running.
+func (s *devServer) serveError(title, message string) *errorPage {
+	body := renderDebugPage(title + "\n\n" + message)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(body)
+	})}
+	server.SetKeepAlivesEnabled(false)
+
+	page := &errorPage{
+		listener: &pausableListener{deadlineListener: s.listener, stop: make(chan struct{})},
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(page.done)
+		server.Serve(page.listener)
+	}()
+	return page
+}
+
+// stop stops serving the error page without closing the shared listener.
+func (p *errorPage) stop() {
+	if p == nil {
+		return
+	}
+	p.listener.Close()
+	<-p.done
+}
+
+// renderDebugPage renders the debug error view, falling back to plain text.
+func renderDebugPage(message string) []byte {
+	var buf bytes.Buffer
+	tmpl, err := template.ParseFiles("resources/views/errors/debug.html")
+	if err == nil && tmpl.Execute(&buf, message) == nil {
+		return buf.Bytes()
+	}
+	return []byte("<pre>" + template.HTMLEscapeString(message) + "</pre>")
+}
+
+// pausableListener accepts from a shared listener until closed, leaving the shared listener open.
+type pausableListener struct {
+	deadlineListener
+	stop chan struct{}
+	once sync.Once
+}
+
+// Accept polls the shared listener so that Close takes effect promptly.
+func (l *pausableListener) Accept() (net.Conn, error) {
+	for {
+		select {
+		case <-l.stop:
+			return nil, net.ErrClosed
+		default:
+		}
+
+		l.SetDeadline(time.Now().Add(200 * time.Millisecond))
+		conn, err := l.deadlineListener.Accept()
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			continue
+		}
+		return conn, err
+	}
+}
+
+// Close stops accepting without closing the shared listener.
+func (l *pausableListener) Close() error {
+	l.once.Do(func() {
+		close(l.stop)
+	})
+	return nil
+}
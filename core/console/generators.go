@@ -0,0 +1,313 @@
+package console
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed stubs/*.stub
+var defaultStubs embed.FS
+
+// StubsDir is the project directory whose stubs override the embedded defaults.
+const StubsDir = "stubs"
+
+// generator describes a make:* command producing one file under app/.
+type generator struct {
+	kind        string // Stub name without extension
+	dir         string // Directory the file is generated in
+	suffix      string // Suffix appended to the type name, e.g. "Controller"
+	description string
+}
+
+var generators = []generator{
+	{kind: "controller", dir: "app/controllers", suffix: "Controller", description: "Create a new controller"},
+	{kind: "middleware", dir: "app/middlewares", suffix: "Middleware", description: "Create a new middleware"},
+	{kind: "provider", dir: "app/providers", suffix: "Provider", description: "Create a new service provider"},
+	{kind: "command", dir: "app/commands", suffix: "Command", description: "Create a new console command"},
+	{kind: "model", dir: "app/models", description: "Create a new model"},
+	{kind: "job", dir: "app/jobs", description: "Create a new queued job"},
+}
+
+// stubData is passed to stub templates.
+type stubData struct {
+	Name      string // Type or function name, e.g. "UserProfileController"
+	Title     string // Human readable name, e.g. "user profile"
+	Module    string // Go module path of the project
+	Framework string // Module path of the framework
+	Signature string // Console command signature
+}
+
+// RegisterGeneratorCommands registers the make:* code generators and stub:publish.
+func RegisterGeneratorCommands(app *Application) error {
+	for _, g := range generators {
+		g := g
+		signature := "make:" + g.kind + " {name : The name of the " + g.kind + "} {--force : Overwrite the file if it exists}"
+		switch g.kind {
+		case "controller":
+			signature += " {--api : Generate an API controller returning JSON}" +
+				" {--route= : Register a route with this path in routes/web.go, or routes/api.go with --api}" +
+				" {--method=GET : The HTTP method of the registered route}"
+		case "command":
+			signature += " {--command= : The signature of the generated command}"
+		}
+		if err := app.Add(NewCommand(signature, g.description, func(ctx *Context) error {
+			return g.generate(ctx)
+		})); err != nil {
+			return err
+		}
+	}
+
+	return app.Add(NewCommand("stub:publish {--force : Overwrite existing stubs}",
+		"Publish the generator stubs for customization", stubPublishCommand))
+}
+
+// generate renders the stub of the generator and writes it under its directory.
+func (g generator) generate(ctx *Context) error {
+	base := studlyCase(ctx.Argument("name"))
+	if base == "" {
+		return fmt.Errorf("invalid %s name '%s'", g.kind, ctx.Argument("name"))
+	}
+	name := base
+	if g.suffix != "" && !strings.HasSuffix(name, g.suffix) {
+		name += g.suffix
+	}
+
+	module, err := modulePath()
+	if err != nil {
+		return err
+	}
+	data := stubData{
+		Name:      name,
+		Title:     strings.Join(words(strings.TrimSuffix(name, g.suffix)), " "),
+		Module:    module,
+		Framework: frameworkModule,
+	}
+
+	stub := g.kind
+	if g.kind == "controller" && ctx.HasOption("api") {
+		stub = "controller.api"
+	}
+	if g.kind == "command" {
+		data.Signature = ctx.Option("command")
+		if data.Signature == "" {
+			data.Signature = "app:" + strings.Join(words(base), "-")
+		}
+	}
+
+	path := filepath.Join(g.dir, snakeCase(name)+".go")
+	if _, err := os.Stat(path); err == nil && !ctx.HasOption("force") {
+		return fmt.Errorf("%s already exists", path)
+	}
+
+	source, err := renderStub(stub, data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(g.dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, source, 0644); err != nil {
+		return err
+	}
+	ctx.Output.Info("%s [%s] created successfully.", studlyCase(g.kind), path)
+
+	if g.kind == "controller" && ctx.Option("route") != "" {
+		routesFile := "routes/web.go"
+		if ctx.HasOption("api") {
+			routesFile = "routes/api.go"
+		}
+		if err := registerRoute(routesFile, ctx.Option("method"), ctx.Option("route"), "controllers."+name); err != nil {
+			return err
+		}
+		ctx.Output.Info("Route [%s %s] registered in [%s].", strings.ToUpper(ctx.Option("method")), ctx.Option("route"), routesFile)
+	}
+	if g.kind == "provider" {
+		ctx.Output.Line("Register it in bootstrap/init.go with kernel.RegisterProvider(&providers.%s{}).", name)
+	}
+	if g.kind == "command" {
+		ctx.Output.Line("Register it in app/commands/commands.go with app.Add(%s()).", name)
+	}
+	if g.kind == "job" {
+		ctx.Output.Line("Register it in app/jobs/jobs.go with q.Register(%s{}).", name)
+	}
+	return nil
+}
+
+// renderStub renders a stub from the project stubs directory or the embedded defaults.
+func renderStub(name string, data interface{}) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Join(StubsDir, name+".stub"))
+	if errors.Is(err, os.ErrNotExist) {
+		content, err = defaultStubs.ReadFile("stubs/" + name + ".stub")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading stub '%s': %w", name, err)
+	}
+
+	tmpl, err := template.New(name).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing stub '%s': %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("rendering stub '%s': %w", name, err)
+	}
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting stub '%s': %w", name, err)
+	}
+	return source, nil
+}
+
+// stubPublishCommand copies the embedded stubs into the project stubs directory.
+func stubPublishCommand(ctx *Context) error {
+	entries, err := defaultStubs.ReadDir("stubs")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(StubsDir, 0755); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(StubsDir, entry.Name())
+		if _, err := os.Stat(path); err == nil && !ctx.HasOption("force") {
+			ctx.Output.Warn("%s already exists, skipping", path)
+			continue
+		}
+		content, err := defaultStubs.ReadFile("stubs/" + entry.Name())
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return err
+		}
+		ctx.Output.Info("Stub [%s] published.", path)
+	}
+	return nil
+}
+
+var (
+	registerFuncPattern = regexp.MustCompile(`func Register\w*Routes\(`)
+	groupPattern        = regexp.MustCompile(`(\w+) := router\.Group\("([^"]*)"\)`)
+)
+
+// registerRoute adds a route for handler to the registration function in a routes file.
+func registerRoute(routesFile, method, path, handler string) error {
+	methods := map[string]string{"GET": "Get", "POST": "Post", "PUT": "Put", "PATCH": "Patch", "DELETE": "Delete"}
+	fn, ok := methods[strings.ToUpper(method)]
+	if !ok {
+		return fmt.Errorf("unsupported route method '%s'", method)
+	}
+
+	content, err := os.ReadFile(routesFile)
+	if err != nil {
+		return err
+	}
+	source := string(content)
+
+	loc := registerFuncPattern.FindStringIndex(source)
+	if loc == nil {
+		return fmt.Errorf("no route registration function found in %s", routesFile)
+	}
+	end := strings.Index(source[loc[0]:], "\n}")
+	if end < 0 {
+		return fmt.Errorf("unterminated route registration function in %s", routesFile)
+	}
+	end += loc[0]
+	body := source[loc[0]:end]
+
+	// Register on the route group the file already uses, relative to its prefix
+	receiver := "router"
+	if match := groupPattern.FindStringSubmatch(body); match != nil {
+		receiver = match[1]
+		if match[2] != "" && strings.HasPrefix(path, match[2]+"/") {
+			path = strings.TrimPrefix(path, match[2])
+		}
+	}
+
+	line := fmt.Sprintf("%s.%s(%q, %s)", receiver, fn, path, handler)
+	if strings.Contains(body, line) {
+		return nil
+	}
+
+	updated, err := format.Source([]byte(source[:end] + "\n\t" + line + source[end:]))
+	if err != nil {
+		return fmt.Errorf("formatting %s: %w", routesFile, err)
+	}
+	return os.WriteFile(routesFile, updated, 0644)
+}
+
+// modulePath reads the module path from go.mod in the working directory.
+func modulePath() (string, error) {
+	content, err := os.ReadFile("go.mod")
+	if err != nil {
+		return "", fmt.Errorf("reading go.mod, run this command from the project root: %w", err)
+	}
+	if module := readModule(content); module != "" {
+		return module, nil
+	}
+	return "", errors.New("no module directive found in go.mod")
+}
+
+// readModule returns the module path declared by a go.mod file, or "" if there is none.
+func readModule(content []byte) string {
+	for _, line := range strings.Split(string(content), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "module" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// words splits a name such as "UserProfile", "user_profile" or "user-profile" into lower case words.
+func words(name string) []string {
+	result := []string{}
+	current := []rune{}
+	runes := []rune(name)
+	flush := func() {
+		if len(current) > 0 {
+			result = append(result, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1]))):
+			flush()
+		}
+		current = append(current, r)
+	}
+	flush()
+	return result
+}
+
+// studlyCase converts a name to an exported Go identifier, e.g. "user_profile" to "UserProfile".
+func studlyCase(name string) string {
+	var b strings.Builder
+	for _, word := range words(name) {
+		first, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(word[size:])
+	}
+	return b.String()
+}
+
+// snakeCase converts a name to a file name, e.g. "UserProfileController" to "user_profile_controller".
+func snakeCase(name string) string {
+	return strings.Join(words(name), "_")
+}
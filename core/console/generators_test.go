@@ -0,0 +1,42 @@
+package console
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGeneratorNames(t *testing.T) {
+	tests := []struct {
+		name, studly, snake string
+	}{
+		{"user_profile", "UserProfile", "user_profile"},
+		{"user-profile", "UserProfile", "user_profile"},
+		{"UserProfileController", "UserProfileController", "user_profile_controller"},
+		{"HTTPServer", "HttpServer", "http_server"},
+		{"sendEmail2", "SendEmail2", "send_email2"},
+		{"élan_vital", "ÉlanVital", "élan_vital"},
+		{"über", "Über", "über"},
+		{"  ", "", ""},
+	}
+	for _, test := range tests {
+		if got := studlyCase(test.name); got != test.studly {
+			t.Errorf("studlyCase(%q) = %q, want %q", test.name, got, test.studly)
+		}
+		if got := snakeCase(test.name); got != test.snake {
+			t.Errorf("snakeCase(%q) = %q, want %q", test.name, got, test.snake)
+		}
+	}
+}
+
+func TestCommandStubQuotesTheSignature(t *testing.T) {
+	signature := `mail:send {user : The "user" ID, e.g. C:\users\1}` + "\n{--queue=}"
+	source, err := renderStub("command", stubData{Name: "SendMail", Title: `send "mail"`, Framework: frameworkModule, Signature: signature})
+	if err != nil {
+		t.Fatalf("rendering a signature with quotes, backslashes and a newline: %v", err)
+	}
+	want := "console.NewCommand(" + strconv.Quote(signature) + ", " + strconv.Quote(`send "mail"`) + ","
+	if !strings.Contains(string(source), want) {
+		t.Fatalf("rendered\n%s\nwant it to contain %s", source, want)
+	}
+}
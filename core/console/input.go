@@ -0,0 +1,112 @@
+package console
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Input holds the arguments and options passed to a command.
+type Input struct {
+	arguments map[string][]string
+	options   map[string]string
+}
+
+// ParseInput binds command line arguments to a command definition.
+func ParseInput(definition *Definition, args []string) (*Input, error) {
+	input := &Input{
+		arguments: make(map[string][]string),
+		options:   make(map[string]string),
+	}
+	for _, option := range definition.Options {
+		if option.AcceptValue && option.Default != "" {
+			input.options[option.Name] = option.Default
+		}
+	}
+
+	positional := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.TrimLeft(arg, "-"), "", false
+		if j := strings.Index(name, "="); j >= 0 {
+			name, value, hasValue = name[:j], name[j+1:], true
+		}
+
+		option := definition.option(name)
+		if option == nil {
+			return nil, fmt.Errorf("unknown option '%s'", arg)
+		}
+		if !option.AcceptValue {
+			if hasValue {
+				return nil, fmt.Errorf("option '--%s' does not accept a value", option.Name)
+			}
+			input.options[option.Name] = "true"
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("option '--%s' requires a value", option.Name)
+			}
+			i++
+			value = args[i]
+		}
+		input.options[option.Name] = value
+	}
+
+	for _, argument := range definition.Arguments {
+		if len(positional) == 0 {
+			if argument.Required {
+				return nil, fmt.Errorf("missing required argument '%s'", argument.Name)
+			}
+			if argument.Default != "" {
+				input.arguments[argument.Name] = []string{argument.Default}
+			}
+			continue
+		}
+		if argument.Variadic {
+			input.arguments[argument.Name] = positional
+			positional = nil
+			continue
+		}
+		input.arguments[argument.Name] = positional[:1]
+		positional = positional[1:]
+	}
+	if len(positional) > 0 {
+		return nil, fmt.Errorf("too many arguments: %s", strings.Join(positional, " "))
+	}
+
+	return input, nil
+}
+
+// Argument returns the value of an argument, or an empty string if it was not given.
+func (in *Input) Argument(name string) string {
+	values := in.arguments[name]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Arguments returns all values of a variadic argument.
+func (in *Input) Arguments(name string) []string {
+	return in.arguments[name]
+}
+
+// Option returns the value of an option, or an empty string if it was not given.
+func (in *Input) Option(name string) string {
+	return in.options[name]
+}
+
+// HasOption reports whether a flag was given or an option has a value.
+func (in *Input) HasOption(name string) bool {
+	_, ok := in.options[name]
+	return ok
+}
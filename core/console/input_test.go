@@ -0,0 +1,86 @@
+package console
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInput(t *testing.T) {
+	definition, err := ParseSignature("deploy {target} {services*} {--force} {--tag=latest} {--r|region=}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args     []string
+		target   string
+		services []string
+		options  map[string]string
+	}{
+		{
+			args:     []string{"prod", "api"},
+			target:   "prod",
+			services: []string{"api"},
+			options:  map[string]string{"tag": "latest"},
+		},
+		{
+			args:     []string{"prod", "api", "worker", "--force"},
+			target:   "prod",
+			services: []string{"api", "worker"},
+			options:  map[string]string{"force": "true", "tag": "latest"},
+		},
+		{
+			args:     []string{"--tag=v2", "-r", "eu", "prod", "api"},
+			target:   "prod",
+			services: []string{"api"},
+			options:  map[string]string{"tag": "v2", "region": "eu"},
+		},
+		{
+			args:     []string{"--tag", "v3", "prod", "--region=", "api"},
+			target:   "prod",
+			services: []string{"api"},
+			options:  map[string]string{"tag": "v3", "region": ""},
+		},
+		{
+			args:     []string{"prod", "--", "--force", "-"},
+			target:   "prod",
+			services: []string{"--force", "-"},
+			options:  map[string]string{"tag": "latest"},
+		},
+	}
+	for _, test := range tests {
+		input, err := ParseInput(definition, test.args)
+		if err != nil {
+			t.Errorf("ParseInput(%q): %v", test.args, err)
+			continue
+		}
+		if got := input.Argument("target"); got != test.target {
+			t.Errorf("%q: target is %q, want %q", test.args, got, test.target)
+		}
+		if got := input.Arguments("services"); !reflect.DeepEqual(got, test.services) {
+			t.Errorf("%q: services are %q, want %q", test.args, got, test.services)
+		}
+		if !reflect.DeepEqual(input.options, test.options) {
+			t.Errorf("%q: options are %v, want %v", test.args, input.options, test.options)
+		}
+	}
+}
+
+func TestParseInputRejectsInvalidInput(t *testing.T) {
+	definition, err := ParseSignature("greet {name} {greeting?} {--yell} {--times=}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"ada", "hello", "grace"},
+		{"ada", "--whisper"},
+		{"ada", "--yell=loud"},
+		{"ada", "--times"},
+	} {
+		if _, err := ParseInput(definition, args); err == nil {
+			t.Errorf("ParseInput(%q) succeeded, want an error", args)
+		}
+	}
+}
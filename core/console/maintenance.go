@@ -0,0 +1,86 @@
+package console
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"icepeak/core"
+)
+
+// RegisterMaintenanceCommands registers the down and up commands.
+func RegisterMaintenanceCommands(app *Application) error {
+	return app.Add(
+		NewCommand("down"+
+			" {--retry= : Seconds sent in the Retry-After header}"+
+			" {--secret= : Path that sets a cookie bypassing maintenance mode}"+
+			" {--with-secret : Generate a random bypass secret}"+
+			" {--allow= : Comma-separated IP addresses or CIDR ranges allowed through}"+
+			" {--message= : Message shown to clients}",
+			"Put the application into maintenance mode", downCommand),
+		NewCommand("up", "Bring the application out of maintenance mode", upCommand),
+	)
+}
+
+// downCommand writes the maintenance state file.
+func downCommand(ctx *Context) error {
+	state := &core.MaintenanceState{
+		Time:    time.Now().Unix(),
+		Secret:  strings.Trim(ctx.Option("secret"), "/"),
+		Message: ctx.Option("message"),
+	}
+
+	if retry := ctx.Option("retry"); retry != "" {
+		seconds, err := strconv.Atoi(retry)
+		if err != nil || seconds < 0 {
+			return fmt.Errorf("invalid --retry '%s', expected a number of seconds", retry)
+		}
+		state.Retry = seconds
+	}
+	if ctx.HasOption("with-secret") && state.Secret == "" {
+		secret := make([]byte, 16)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		state.Secret = hex.EncodeToString(secret)
+	}
+	for _, entry := range strings.Split(ctx.Option("allow"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+			return fmt.Errorf("invalid --allow entry '%s', expected an IP address or CIDR range", entry)
+		}
+		state.Allow = append(state.Allow, entry)
+	}
+
+	if err := core.WriteMaintenanceState(core.MaintenanceFile, state); err != nil {
+		return err
+	}
+	ctx.Output.Info("Application is now in maintenance mode.")
+	if state.Secret != "" {
+		ctx.Output.Line("Visit /%s to bypass maintenance mode.", state.Secret)
+	}
+	return nil
+}
+
+// upCommand removes the maintenance state file, even one that can't be read, as servers
+// stay down while it exists.
+func upCommand(ctx *Context) error {
+	if _, err := os.Stat(core.MaintenanceFile); errors.Is(err, os.ErrNotExist) {
+		ctx.Output.Info("Application is already up.")
+		return nil
+	}
+	if err := core.RemoveMaintenanceState(core.MaintenanceFile); err != nil {
+		return err
+	}
+	ctx.Output.Info("Application is now live.")
+	return nil
+}
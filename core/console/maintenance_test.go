@@ -0,0 +1,46 @@
+package console
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"icepeak/core"
+)
+
+func TestUpRemovesUnreadableMaintenanceState(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	if err := os.MkdirAll(filepath.Dir(core.MaintenanceFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(core.MaintenanceFile, []byte("{corrupt"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	ctx := &Context{Output: &Output{Out: &out, Err: &out}}
+	if err := upCommand(ctx); err != nil {
+		t.Fatalf("up returned %v, want the corrupt state file removed", err)
+	}
+	if _, err := os.Stat(core.MaintenanceFile); !os.IsNotExist(err) {
+		t.Fatalf("state file still exists after up: %v", err)
+	}
+	if !strings.Contains(out.String(), "now live") {
+		t.Fatalf("up wrote %q, want the application reported live", out.String())
+	}
+
+	out.Reset()
+	if err := upCommand(ctx); err != nil || !strings.Contains(out.String(), "already up") {
+		t.Fatalf("second up returned %v and wrote %q, want it reported already up", err, out.String())
+	}
+}
@@ -0,0 +1,181 @@
+package console
+
+import (
+	"bytes"
+	"crypto/rand"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed all:skeleton
+var skeleton embed.FS
+
+// frameworkModule is the module path of the framework, which projects import it with.
+const frameworkModule = "icepeak"
+
+// fullOnly lists skeleton paths left out of --minimal (API-only) projects.
+var fullOnly = []string{
+	"resources/views/welcome/",
+	"routes/web.go.tmpl",
+	"app/controllers/home_controller.go.tmpl",
+}
+
+// skeletonData is passed to skeleton templates.
+type skeletonData struct {
+	Name      string // Application name
+	Module    string // Go module path of the new project
+	Framework string // Module path of the framework
+	Key       string // Generated application key
+	Minimal   bool   // API-only project
+}
+
+// RegisterNewCommand registers the new command that scaffolds projects.
+func RegisterNewCommand(app *Application) error {
+	return app.Add(NewCommand(
+		"new {name : The directory of the new project}"+
+			" {--module= : The Go module path, defaults to the directory name}"+
+			" {--minimal : Create an API-only project without web routes and views}"+
+			" {--framework= : The framework source directory the project depends on, defaults to the working directory}",
+		"Create a new Icepeak project", newCommand))
+}
+
+// newCommand creates the project skeleton. Existing files are kept, so running it again only fills in what is missing.
+func newCommand(ctx *Context) error {
+	dir := ctx.Argument("name")
+	module := ctx.Option("module")
+	if module == "" {
+		module = filepath.Base(filepath.Clean(dir))
+	}
+
+	framework := ctx.Option("framework")
+	if framework == "" {
+		framework = "."
+	}
+	framework, err := filepath.Abs(framework)
+	if err != nil {
+		return err
+	}
+	if content, err := os.ReadFile(filepath.Join(framework, "go.mod")); err != nil || readModule(content) != frameworkModule {
+		return fmt.Errorf("framework sources not found in '%s', pass --framework with the directory of the %s module", framework, frameworkModule)
+	}
+
+	key, err := generateAppKey()
+	if err != nil {
+		return err
+	}
+	data := skeletonData{
+		Name:      filepath.Base(filepath.Clean(dir)),
+		Module:    module,
+		Framework: frameworkModule,
+		Key:       key,
+		Minimal:   ctx.HasOption("minimal"),
+	}
+
+	created, kept := 0, 0
+	write := func(target string, content []byte) error {
+		if _, err := os.Stat(target); err == nil {
+			kept++
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		created++
+		return os.WriteFile(target, content, 0644)
+	}
+
+	// Render the application skeleton
+	err = fs.WalkDir(skeleton, "skeleton", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel := strings.TrimPrefix(name, "skeleton/")
+		if data.Minimal && isFullOnly(rel) {
+			return nil
+		}
+
+		content, err := skeleton.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(rel, ".tmpl") {
+			rel = strings.TrimSuffix(rel, ".tmpl")
+			tmpl, err := template.New(rel).Parse(string(content))
+			if err != nil {
+				return fmt.Errorf("parsing skeleton '%s': %w", rel, err)
+			}
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, data); err != nil {
+				return fmt.Errorf("rendering skeleton '%s': %w", rel, err)
+			}
+			content = buf.Bytes()
+		}
+		return write(filepath.Join(dir, filepath.FromSlash(rel)), content)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Depend on the framework module, replaced with its sources, rather than copying it
+	for _, name := range []string{"go.mod", "go.sum"} {
+		content, err := os.ReadFile(filepath.Join(framework, name))
+		if err != nil {
+			return err
+		}
+		if name == "go.mod" {
+			content = requireFramework(rewriteModule(content, module), framework)
+		}
+		if err := write(filepath.Join(dir, name), content); err != nil {
+			return err
+		}
+	}
+
+	ctx.Output.Info("Application [%s] ready in [%s]: %d files created, %d existing files kept.", module, dir, created, kept)
+	ctx.Output.Line("\n  cd %s\n  go run main.go", dir)
+	return nil
+}
+
+// isFullOnly reports whether a skeleton path is left out of minimal projects.
+func isFullOnly(rel string) bool {
+	for _, prefix := range fullOnly {
+		if rel == prefix || (strings.HasSuffix(prefix, "/") && strings.HasPrefix(rel, prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteModule replaces the module directive of a go.mod file.
+func rewriteModule(content []byte, module string) []byte {
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "module" {
+			lines[i] = "module " + module
+			break
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// requireFramework adds the framework to the requirements of a go.mod file, replaced with
+// the sources in dir.
+func requireFramework(content []byte, dir string) []byte {
+	content = bytes.TrimRight(content, "\n")
+	return append(content, fmt.Sprintf("\n\nrequire %s v0.0.0\n\nreplace %s => %s\n", frameworkModule, frameworkModule, filepath.ToSlash(dir))...)
+}
+
+// generateAppKey returns a random 32-byte key in the APP_KEY format.
+func generateAppKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", errors.New("could not generate application key")
+	}
+	return "base64:" + base64.StdEncoding.EncodeToString(key), nil
+}
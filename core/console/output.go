@@ -0,0 +1,70 @@
+package console
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Output writes command output to standard output and errors to standard error.
+type Output struct {
+	Out io.Writer
+	Err io.Writer
+}
+
+// Line writes a plain line.
+func (o *Output) Line(format string, args ...interface{}) {
+	fmt.Fprintf(o.Out, format+"\n", args...)
+}
+
+// Info writes an informational line.
+func (o *Output) Info(format string, args ...interface{}) {
+	fmt.Fprintf(o.Out, "INFO  "+format+"\n", args...)
+}
+
+// Warn writes a warning line to the error stream.
+func (o *Output) Warn(format string, args ...interface{}) {
+	fmt.Fprintf(o.Err, "WARN  "+format+"\n", args...)
+}
+
+// Error writes an error line to the error stream.
+func (o *Output) Error(format string, args ...interface{}) {
+	fmt.Fprintf(o.Err, "ERROR "+format+"\n", args...)
+}
+
+// Table writes rows as aligned columns under the given headers.
+func (o *Output) Table(headers []string, rows [][]string) {
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = len(header)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) && len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	writeRow := func(cells []string) {
+		padded := make([]string, len(widths))
+		for i := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			padded[i] = cell + strings.Repeat(" ", widths[i]-len(cell))
+		}
+		fmt.Fprintln(o.Out, strings.TrimRight(strings.Join(padded, "  "), " "))
+	}
+
+	writeRow(headers)
+	separators := make([]string, len(widths))
+	for i, width := range widths {
+		separators[i] = strings.Repeat("-", width)
+	}
+	writeRow(separators)
+	for _, row := range rows {
+		writeRow(row)
+	}
+}
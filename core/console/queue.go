@@ -0,0 +1,200 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"icepeak/core/queue"
+)
+
+// RegisterQueueCommands registers the queue:work command and the failed job commands.
+func RegisterQueueCommands(app *Application) error {
+	return app.Add(
+		NewCommand("queue:work"+
+			" {--queue=default : Comma-separated queues to work, highest priority first}"+
+			" {--concurrency=1 : Number of jobs processed at once}"+
+			" {--sleep=1 : Seconds to wait when the queues are empty}"+
+			" {--timeout=60 : Seconds a job may run before it is cancelled, at most 240}"+
+			" {--stop-when-empty : Stop once the queues are empty}",
+			"Process jobs on the queue until interrupted", queueWorkCommand),
+		NewCommand("queue:failed", "List the failed jobs", queueFailedCommand),
+		NewCommand("queue:retry {id?* : The IDs of the failed jobs} {--all : Retry every failed job}",
+			"Push failed jobs back onto their queue", queueRetryCommand),
+		NewCommand("queue:forget {id : The ID of the failed job}", "Delete a failed job", queueForgetCommand),
+		NewCommand("queue:flush", "Delete all failed jobs", queueFlushCommand),
+	)
+}
+
+// queueWorkCommand processes jobs until SIGINT or SIGTERM, letting running jobs finish.
+func queueWorkCommand(ctx *Context) error {
+	kernel := ctx.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+	if err := kernel.Boot(); err != nil {
+		return err
+	}
+
+	worker := kernel.NewQueueWorker()
+	for _, name := range strings.Split(ctx.Option("queue"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			worker.Queues = append(worker.Queues, name)
+		}
+	}
+	concurrency, err := positiveOption(ctx, "concurrency")
+	if err != nil {
+		return err
+	}
+	sleep, err := positiveOption(ctx, "sleep")
+	if err != nil {
+		return err
+	}
+	timeout, err := positiveOption(ctx, "timeout")
+	if err != nil {
+		return err
+	}
+	if max := int(queue.MaxTimeout / time.Second); timeout > max {
+		return fmt.Errorf("invalid --timeout '%d', jobs are reserved for %v so it must be at most %d", timeout, queue.DefaultReservation, max)
+	}
+	worker.Concurrency = concurrency
+	worker.Sleep = time.Duration(sleep) * time.Second
+	worker.Timeout = time.Duration(timeout) * time.Second
+	worker.StopWhenEmpty = ctx.HasOption("stop-when-empty")
+
+	runCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx.Output.Info("Processing jobs from [%s] with %d workers. Press Ctrl+C to stop.", strings.Join(worker.Queues, ", "), worker.Concurrency)
+	worker.Run(runCtx)
+	if runCtx.Err() != nil {
+		ctx.Output.Info("Workers stopped after finishing their jobs.")
+	}
+	return nil
+}
+
+// queueFailedCommand lists the failed jobs.
+func queueFailedCommand(ctx *Context) error {
+	kernel := ctx.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+	if err := kernel.Boot(); err != nil {
+		return err
+	}
+
+	failed, err := kernel.Queue().Failed(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(failed) == 0 {
+		ctx.Output.Info("No failed jobs.")
+		return nil
+	}
+
+	rows := [][]string{}
+	for _, envelope := range failed {
+		rows = append(rows, []string{
+			envelope.ID,
+			envelope.Queue,
+			envelope.Job,
+			strconv.Itoa(envelope.Attempts),
+			envelope.FailedAt.Format("2006-01-02 15:04:05"),
+			envelope.Error,
+		})
+	}
+	ctx.Output.Table([]string{"ID", "Queue", "Job", "Attempts", "Failed At", "Error"}, rows)
+	return nil
+}
+
+// queueRetryCommand pushes failed jobs back onto their queue.
+func queueRetryCommand(ctx *Context) error {
+	kernel := ctx.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+	if err := kernel.Boot(); err != nil {
+		return err
+	}
+
+	ids := ctx.Arguments("id")
+	if ctx.HasOption("all") {
+		failed, err := kernel.Queue().Failed(context.Background())
+		if err != nil {
+			return err
+		}
+		if len(failed) == 0 {
+			ctx.Output.Info("No failed jobs.")
+			return nil
+		}
+		ids = nil
+		for _, envelope := range failed {
+			ids = append(ids, envelope.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return &ExitError{Code: ExitUsage, Err: errors.New("pass the IDs of the jobs to retry, or --all")}
+	}
+
+	for _, id := range ids {
+		if err := kernel.Queue().Retry(context.Background(), id); err != nil {
+			return err
+		}
+		ctx.Output.Info("Job [%s] pushed back onto its queue.", id)
+	}
+	return nil
+}
+
+// queueForgetCommand deletes a failed job.
+func queueForgetCommand(ctx *Context) error {
+	kernel := ctx.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+	if err := kernel.Boot(); err != nil {
+		return err
+	}
+
+	id := ctx.Argument("id")
+	if err := kernel.Queue().Forget(context.Background(), id); err != nil {
+		return err
+	}
+	ctx.Output.Info("Failed job [%s] deleted.", id)
+	return nil
+}
+
+// queueFlushCommand deletes all failed jobs.
+func queueFlushCommand(ctx *Context) error {
+	kernel := ctx.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+	if err := kernel.Boot(); err != nil {
+		return err
+	}
+
+	failed, err := kernel.Queue().Failed(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, envelope := range failed {
+		if err := kernel.Queue().Forget(context.Background(), envelope.ID); err != nil {
+			return err
+		}
+	}
+	ctx.Output.Info("Deleted %d failed jobs.", len(failed))
+	return nil
+}
+
+// positiveOption parses an option that must be a positive number.
+func positiveOption(ctx *Context, name string) (int, error) {
+	n, err := strconv.Atoi(ctx.Option(name))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid --%s '%s', expected a positive number", name, ctx.Option(name))
+	}
+	return n, nil
+}
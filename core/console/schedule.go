@@ -0,0 +1,92 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RegisterScheduleCommands registers the schedule:list, schedule:run and schedule:work commands.
+func RegisterScheduleCommands(app *Application) error {
+	return app.Add(
+		NewCommand("schedule:list", "List the scheduled tasks", scheduleListCommand),
+		NewCommand("schedule:run", "Run the scheduled tasks that are due now; call it from cron every minute", scheduleRunCommand),
+		NewCommand("schedule:work", "Run the scheduled tasks in the foreground until interrupted", scheduleWorkCommand),
+	)
+}
+
+// scheduleListCommand lists the scheduled tasks with their next run.
+func scheduleListCommand(ctx *Context) error {
+	kernel := ctx.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+
+	scheduler := kernel.Schedule()
+	tasks := scheduler.Tasks()
+	if len(tasks) == 0 {
+		ctx.Output.Info("No tasks are scheduled.")
+		return nil
+	}
+
+	now := time.Now()
+	rows := [][]string{}
+	for _, task := range tasks {
+		next := "never"
+		if err := task.Err(); err != nil {
+			next = "invalid: " + err.Error()
+		} else if at := scheduler.NextRun(task, now); !at.IsZero() {
+			next = at.Format("2006-01-02 15:04 MST")
+		}
+
+		flags := []string{}
+		if !task.Overlapping() {
+			flags = append(flags, "without overlapping")
+		}
+		if task.RunsOnOneServer() {
+			flags = append(flags, "one server")
+		}
+		rows = append(rows, []string{task.Expression(), task.Name(), task.Summary(), next, strings.Join(flags, ", ")})
+	}
+	ctx.Output.Table([]string{"Cron", "Task", "Description", "Next Due", "Options"}, rows)
+	return nil
+}
+
+// scheduleRunCommand runs the tasks due in the current minute.
+func scheduleRunCommand(ctx *Context) error {
+	kernel := ctx.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+	if err := kernel.Boot(); err != nil {
+		return err
+	}
+
+	scheduler := kernel.Schedule()
+	now := time.Now()
+	if len(scheduler.Due(now)) == 0 {
+		ctx.Output.Info("No scheduled tasks are due.")
+		return nil
+	}
+	return scheduler.RunDue(context.Background(), now)
+}
+
+// scheduleWorkCommand runs the scheduler until SIGINT or SIGTERM.
+func scheduleWorkCommand(ctx *Context) error {
+	kernel := ctx.Kernel()
+	if kernel == nil {
+		return errors.New("no kernel available")
+	}
+	if err := kernel.Boot(); err != nil {
+		return err
+	}
+
+	runCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx.Output.Info("Running scheduled tasks. Press Ctrl+C to stop.")
+	kernel.Schedule().Run(runCtx)
+	return nil
+}
@@ -0,0 +1,142 @@
+package console
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Argument describes a positional argument declared in a command signature.
+type Argument struct {
+	Name        string
+	Description string
+	Required    bool
+	Variadic    bool
+	Default     string
+}
+
+// Option describes an option declared in a command signature.
+type Option struct {
+	Name        string
+	Shortcut    string
+	Description string
+	AcceptValue bool
+	Default     string
+}
+
+// Definition is the parsed form of a command signature.
+type Definition struct {
+	Name      string
+	Arguments []*Argument
+	Options   []*Option
+}
+
+var tokenPattern = regexp.MustCompile(`\{\s*([^}]*?)\s*\}`)
+
+// ParseSignature parses a signature such as
+//
+//	make:controller {name : The controller name} {--api : Generate an API controller}
+//
+// Arguments are written as {name}, {name?}, {name*} or {name=default};
+// options as {--flag}, {--option=}, {--option=default} or {--o|option}.
+func ParseSignature(signature string) (*Definition, error) {
+	name := signature
+	if i := strings.Index(signature, "{"); i >= 0 {
+		name = signature[:i]
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return nil, fmt.Errorf("invalid command name in signature '%s'", signature)
+	}
+
+	definition := &Definition{Name: name}
+	for _, match := range tokenPattern.FindAllStringSubmatch(signature, -1) {
+		token, description := match[1], ""
+		if i := strings.Index(token, " : "); i >= 0 {
+			token, description = strings.TrimSpace(token[:i]), strings.TrimSpace(token[i+3:])
+		}
+
+		if strings.HasPrefix(token, "--") {
+			definition.Options = append(definition.Options, parseOption(token[2:], description))
+			continue
+		}
+
+		argument, err := parseArgument(token, description)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(definition.Arguments); n > 0 && definition.Arguments[n-1].Variadic {
+			return nil, fmt.Errorf("argument '%s' follows variadic argument in '%s'", argument.Name, name)
+		}
+		definition.Arguments = append(definition.Arguments, argument)
+	}
+	return definition, nil
+}
+
+// parseOption parses the body of an option token without its leading dashes.
+func parseOption(token, description string) *Option {
+	option := &Option{Description: description}
+	if i := strings.Index(token, "="); i >= 0 {
+		option.AcceptValue = true
+		option.Default = token[i+1:]
+		token = token[:i]
+	}
+	if i := strings.Index(token, "|"); i >= 0 {
+		option.Shortcut = token[:i]
+		token = token[i+1:]
+	}
+	option.Name = token
+	return option
+}
+
+// parseArgument parses the body of an argument token.
+func parseArgument(token, description string) (*Argument, error) {
+	argument := &Argument{Description: description, Required: true}
+	if i := strings.Index(token, "="); i >= 0 {
+		argument.Default = token[i+1:]
+		argument.Required = false
+		token = token[:i]
+	}
+	if strings.HasSuffix(token, "*") {
+		argument.Variadic = true
+		token = strings.TrimSuffix(token, "*")
+	}
+	if strings.HasSuffix(token, "?") {
+		argument.Required = false
+		token = strings.TrimSuffix(token, "?")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("argument without a name")
+	}
+	argument.Name = token
+	return argument, nil
+}
+
+// option finds an option by name or shortcut.
+func (d *Definition) option(name string) *Option {
+	for _, option := range d.Options {
+		if option.Name == name || (option.Shortcut != "" && option.Shortcut == name) {
+			return option
+		}
+	}
+	return nil
+}
+
+// Usage returns the one-line usage of the command.
+func (d *Definition) Usage() string {
+	parts := []string{d.Name}
+	if len(d.Options) > 0 {
+		parts = append(parts, "[options]")
+	}
+	for _, argument := range d.Arguments {
+		usage := "<" + argument.Name + ">"
+		if argument.Variadic {
+			usage += "..."
+		}
+		if !argument.Required {
+			usage = "[" + usage + "]"
+		}
+		parts = append(parts, usage)
+	}
+	return strings.Join(parts, " ")
+}
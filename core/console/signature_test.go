@@ -0,0 +1,90 @@
+package console
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSignature(t *testing.T) {
+	tests := []struct {
+		signature string
+		name      string
+		arguments []Argument
+		options   []Option
+		usage     string
+	}{
+		{
+			signature: "cache:clear",
+			name:      "cache:clear",
+			usage:     "cache:clear",
+		},
+		{
+			signature: "make:controller {name : The controller name} {--api : Generate an API controller}",
+			name:      "make:controller",
+			arguments: []Argument{{Name: "name", Description: "The controller name", Required: true}},
+			options:   []Option{{Name: "api", Description: "Generate an API controller"}},
+			usage:     "make:controller [options] <name>",
+		},
+		{
+			signature: "mail:send {user?} {template=welcome} {files*}",
+			name:      "mail:send",
+			arguments: []Argument{{Name: "user"}, {Name: "template", Default: "welcome"}, {Name: "files", Required: true, Variadic: true}},
+			usage:     "mail:send [<user>] [<template>] <files>...",
+		},
+		{
+			signature: "serve {--port=8080 : Port} {--host= : Host} {--W|watch}",
+			name:      "serve",
+			options: []Option{
+				{Name: "port", Description: "Port", AcceptValue: true, Default: "8080"},
+				{Name: "host", Description: "Host", AcceptValue: true},
+				{Name: "watch", Shortcut: "W"},
+			},
+			usage: "serve [options]",
+		},
+	}
+	for _, test := range tests {
+		definition, err := ParseSignature(test.signature)
+		if err != nil {
+			t.Errorf("ParseSignature(%q): %v", test.signature, err)
+			continue
+		}
+		if definition.Name != test.name {
+			t.Errorf("%q: name is %q, want %q", test.signature, definition.Name, test.name)
+		}
+		arguments := []Argument{}
+		for _, argument := range definition.Arguments {
+			arguments = append(arguments, *argument)
+		}
+		if len(arguments) > 0 || len(test.arguments) > 0 {
+			if !reflect.DeepEqual(arguments, test.arguments) {
+				t.Errorf("%q: arguments are %+v, want %+v", test.signature, arguments, test.arguments)
+			}
+		}
+		options := []Option{}
+		for _, option := range definition.Options {
+			options = append(options, *option)
+		}
+		if len(options) > 0 || len(test.options) > 0 {
+			if !reflect.DeepEqual(options, test.options) {
+				t.Errorf("%q: options are %+v, want %+v", test.signature, options, test.options)
+			}
+		}
+		if usage := definition.Usage(); usage != test.usage {
+			t.Errorf("%q: usage is %q, want %q", test.signature, usage, test.usage)
+		}
+	}
+}
+
+func TestParseSignatureRejectsInvalidSignatures(t *testing.T) {
+	for _, signature := range []string{
+		"",
+		"{name}",
+		"make controller {name}",
+		"copy {files*} {target}",
+		"greet {=default}",
+	} {
+		if _, err := ParseSignature(signature); err == nil {
+			t.Errorf("ParseSignature(%q) succeeded, want an error", signature)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"icepeak/core/events"
+)
+
+// Names of the lifecycle events dispatched by the kernel.
+const (
+	EventKernelBooted       = "kernel.booted"
+	EventRequestHandled     = "request.handled"
+	EventServerShuttingDown = "server.shutting_down"
+)
+
+// KernelBooted is dispatched once the service providers have booted.
+type KernelBooted struct {
+	Kernel *Kernel
+}
+
+// Name returns the event name.
+func (KernelBooted) Name() string { return EventKernelBooted }
+
+// RequestHandled is dispatched after the kernel has handled a request.
+type RequestHandled struct {
+	Request  *http.Request
+	Status   int
+	Duration time.Duration
+}
+
+// Name returns the event name.
+func (RequestHandled) Name() string { return EventRequestHandled }
+
+// ServerShuttingDown is dispatched when the server starts shutting down.
+type ServerShuttingDown struct{}
+
+// Name returns the event name.
+func (ServerShuttingDown) Name() string { return EventServerShuttingDown }
+
+// Events returns the event dispatcher of this kernel.
+func (k *Kernel) Events() *events.Dispatcher {
+	return k.events
+}
+
+// Dispatch dispatches an event, logging the errors of its listeners.
+func (k *Kernel) Dispatch(ctx context.Context, event events.Event) {
+	if err := k.events.Dispatch(ctx, event); err != nil {
+		if logger := k.Logger(); logger != nil {
+			logger.Error(fmt.Sprintf("Event %s: %v", event.Name(), err))
+		}
+	}
+}
+
+// registerEventErrorHandler logs the errors of asynchronous listeners.
+func (k *Kernel) registerEventErrorHandler() {
+	k.events.OnError(func(event events.Event, err error) {
+		if logger := k.Logger(); logger != nil {
+			logger.Error(fmt.Sprintf("Event %s: %v", event.Name(), err))
+		}
+	})
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code before writing it.
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records an implicit 200 status before writing the body.
+func (r *statusRecorder) Write(body []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(body)
+}
+
+// Flush sends buffered data to the client when the underlying writer supports it.
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
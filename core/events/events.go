@@ -0,0 +1,190 @@
+// Package events dispatches application events to synchronous and asynchronous listeners.
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Event is dispatched to the listeners subscribed to its name, e.g. "user.registered".
+type Event interface {
+	Name() string
+}
+
+// Listener handles a dispatched event.
+type Listener func(ctx context.Context, event Event) error
+
+// ListenOption configures a subscription.
+type ListenOption func(*subscription)
+
+// Priority sets the priority of a listener; listeners with a higher priority run first.
+// The default priority is 0.
+func Priority(priority int) ListenOption {
+	return func(s *subscription) {
+		s.priority = priority
+	}
+}
+
+// Async runs the listener in its own goroutine. Dispatch does not wait for it and its
+// errors go to the error handler instead of the caller.
+func Async() ListenOption {
+	return func(s *subscription) {
+		s.async = true
+	}
+}
+
+// subscription is a listener subscribed to an event name pattern.
+type subscription struct {
+	id       uint64
+	pattern  string
+	listener Listener
+	priority int
+	async    bool
+}
+
+// Dispatcher delivers events to the listeners subscribed to them.
+type Dispatcher struct {
+	subscriptions []*subscription
+	nextID        uint64
+	onError       func(event Event, err error)
+	pending       sync.WaitGroup
+	mu            sync.RWMutex
+}
+
+// NewDispatcher creates an empty dispatcher.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// Listen subscribes a listener to the events whose name matches pattern. Patterns use
+// path.Match syntax, so "user.*" matches "user.registered" and "*" matches every event.
+// The returned function removes the subscription.
+func (d *Dispatcher) Listen(pattern string, listener Listener, options ...ListenOption) (unsubscribe func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.nextID++
+	s := &subscription{id: d.nextID, pattern: pattern, listener: listener}
+	for _, option := range options {
+		option(s)
+	}
+	d.subscriptions = append(d.subscriptions, s)
+
+	// Keep subscriptions ordered by priority, then by subscription order
+	sort.SliceStable(d.subscriptions, func(i, j int) bool {
+		return d.subscriptions[i].priority > d.subscriptions[j].priority
+	})
+
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		for i, existing := range d.subscriptions {
+			if existing.id == s.id {
+				d.subscriptions = append(d.subscriptions[:i:i], d.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// On subscribes a listener to the events of type T, whatever their name.
+func On[T Event](d *Dispatcher, listener func(ctx context.Context, event T) error, options ...ListenOption) (unsubscribe func()) {
+	return d.Listen("*", func(ctx context.Context, event Event) error {
+		typed, ok := event.(T)
+		if !ok {
+			return nil
+		}
+		return listener(ctx, typed)
+	}, options...)
+}
+
+// OnError sets the handler of errors returned or panics raised by asynchronous listeners.
+func (d *Dispatcher) OnError(handler func(event Event, err error)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onError = handler
+}
+
+// HasListeners reports whether any listener is subscribed to the event name.
+func (d *Dispatcher) HasListeners(name string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, s := range d.subscriptions {
+		if matches(s.pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatch delivers an event to its listeners in priority order. Synchronous listeners run
+// before Dispatch returns and all of them run even if some fail; their errors are joined.
+func (d *Dispatcher) Dispatch(ctx context.Context, event Event) error {
+	d.mu.RLock()
+	subscriptions := []*subscription{}
+	for _, s := range d.subscriptions {
+		if matches(s.pattern, event.Name()) {
+			subscriptions = append(subscriptions, s)
+		}
+	}
+	onError := d.onError
+	d.mu.RUnlock()
+
+	errs := []error{}
+	for _, s := range subscriptions {
+		if s.async {
+			d.pending.Add(1)
+			go func(listener Listener) {
+				defer d.pending.Done()
+				// Detach from the caller's cancellation, which usually ends before the listener
+				if err := call(detached{ctx}, listener, event); err != nil && onError != nil {
+					onError(event, err)
+				}
+			}(s.listener)
+			continue
+		}
+		if err := call(ctx, s.listener, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Wait blocks until the asynchronous listeners started so far have finished.
+func (d *Dispatcher) Wait() {
+	d.pending.Wait()
+}
+
+// detached keeps the values of a context without its deadline or cancellation.
+type detached struct {
+	parent context.Context
+}
+
+func (d detached) Deadline() (time.Time, bool)       { return time.Time{}, false }
+func (d detached) Done() <-chan struct{}             { return nil }
+func (d detached) Err() error                        { return nil }
+func (d detached) Value(key interface{}) interface{} { return d.parent.Value(key) }
+
+// call runs a listener, turning a panic into an error.
+func call(ctx context.Context, listener Listener, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("listener of %s panicked: %v", event.Name(), r)
+		}
+	}()
+	return listener(ctx, event)
+}
+
+// matches reports whether an event name matches a subscription pattern.
+func matches(pattern, name string) bool {
+	if pattern == "*" || pattern == name {
+		return true
+	}
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
@@ -0,0 +1,35 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+// named is an event with a fixed name.
+type named string
+
+func (n named) Name() string { return string(n) }
+
+type contextKey struct{}
+
+func TestAsyncListenerOutlivesCallerCancellation(t *testing.T) {
+	d := NewDispatcher()
+	got := make(chan error, 1)
+	d.Listen("user.*", func(ctx context.Context, event Event) error {
+		if ctx.Value(contextKey{}) != "jane" {
+			t.Errorf("lost the value of the dispatching context")
+		}
+		got <- ctx.Err()
+		return nil
+	}, Async())
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey{}, "jane"))
+	cancel()
+	if err := d.Dispatch(ctx, named("user.registered")); err != nil {
+		t.Fatal(err)
+	}
+	d.Wait()
+	if err := <-got; err != nil {
+		t.Fatalf("async listener got a cancelled context: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"icepeak/core/features"
+)
+
+// Features returns the feature flags of this kernel.
+func (k *Kernel) Features() *features.Manager {
+	return k.features
+}
+
+// WithFeatureStore reads feature flags from the given store instead of the one chosen by
+// the FEATURES_DRIVER configuration key.
+func WithFeatureStore(store features.Store) KernelOption {
+	return func(k *Kernel) {
+		k.featureStore = store
+	}
+}
+
+// configureFeatures creates the feature flag manager, registers the "feature" route
+// middleware and refreshes the flags when the FEATURES configuration key changes.
+func (k *Kernel) configureFeatures() {
+	store := k.featureStore
+	if store == nil {
+		store = k.featureStoreFromConfig()
+	}
+	k.features = features.NewManager(store)
+	k.features.OnError(func(err error) {
+		if logger := k.Logger(); logger != nil {
+			logger.Error(fmt.Sprintf("Loading feature flags: %v", err))
+		}
+	})
+
+	// Use as "feature:new_checkout" to answer 404 while the flag is off
+	k.AliasMiddlewareFactory("feature", func(params ...string) func(http.Handler) http.Handler {
+		return k.features.Require(params...)
+	})
+	k.OnConfigChange(func(change ConfigChange) {
+		if change.Has("FEATURES") {
+			k.features.Refresh()
+		}
+	})
+}
+
+// featureStoreFromConfig creates the store chosen by the FEATURES_DRIVER configuration key:
+// "config" (the default) reading the FEATURES key, "file" reading FEATURES_PATH, or "sql"
+// using the *sql.DB bound in the container.
+func (k *Kernel) featureStoreFromConfig() features.Store {
+	switch k.ConfigString("FEATURES_DRIVER", "config") {
+	case "file":
+		return features.NewFileStore(k.ConfigString("FEATURES_PATH", "storage/features.yaml"))
+	case "sql":
+		var store *features.SQLStore
+		var mu sync.Mutex
+		return features.StoreFunc(func(ctx context.Context) (map[string]features.Flag, error) {
+			// Create the store on first use, trying again after a failure such as an outage
+			mu.Lock()
+			if store == nil {
+				created, err := k.featureSQLStore()
+				if err != nil {
+					mu.Unlock()
+					return nil, err
+				}
+				store = created
+			}
+			mu.Unlock()
+			return store.Flags(ctx)
+		})
+	default:
+		return features.StoreFunc(func(ctx context.Context) (map[string]features.Flag, error) {
+			value, _ := k.ConfigValue("FEATURES")
+			return features.ParseFlags(value)
+		})
+	}
+}
+
+// featureSQLStore creates the SQL store on the container's database, creating its table.
+// The migration doesn't use the context of the evaluation, which may be a request's.
+func (k *Kernel) featureSQLStore() (*features.SQLStore, error) {
+	db, err := k.database()
+	if err != nil {
+		return nil, fmt.Errorf("the sql feature driver needs a database: %w", err)
+	}
+	store := features.NewSQLStore(db)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := store.Migrate(ctx); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
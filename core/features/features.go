@@ -0,0 +1,266 @@
+// Package features evaluates feature flags with percentage rollouts and user or tenant targeting.
+package features
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long flags loaded from a store are reused before it is read again.
+const DefaultCacheTTL = 10 * time.Second
+
+// Flag is the definition of a feature flag.
+//
+// A disabled flag is off for everyone. An enabled flag is on for the listed users and
+// tenants, and for the given percentage of the others; without a percentage or targeting
+// lists it is on for everyone.
+type Flag struct {
+	Name       string
+	Enabled    bool
+	Percentage *float64 // Share of users, or tenants without a user, from 0 to 100
+	Users      []string
+	Tenants    []string
+}
+
+// Subject is who a flag is evaluated for.
+type Subject struct {
+	User   string
+	Tenant string
+}
+
+// subjectKey is the context key of the Subject.
+type subjectKey struct{}
+
+// WithSubject returns a context evaluating flags for the subject.
+func WithSubject(ctx context.Context, subject Subject) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFrom returns the subject stored in the context, if any.
+func SubjectFrom(ctx context.Context) Subject {
+	subject, _ := ctx.Value(subjectKey{}).(Subject)
+	return subject
+}
+
+// SubjectMiddleware stores the subject resolved from each request, e.g. the signed-in
+// user, in the request context so flags are evaluated for it.
+func SubjectMiddleware(resolve func(r *http.Request) Subject) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithSubject(r.Context(), resolve(r))))
+		})
+	}
+}
+
+// Store loads flag definitions.
+type Store interface {
+	Flags(ctx context.Context) (map[string]Flag, error)
+}
+
+// StoreFunc adapts a function to the Store interface.
+type StoreFunc func(ctx context.Context) (map[string]Flag, error)
+
+// Flags calls the function.
+func (f StoreFunc) Flags(ctx context.Context) (map[string]Flag, error) {
+	return f(ctx)
+}
+
+// Manager evaluates flags loaded from a store, caching them briefly.
+type Manager struct {
+	store   Store
+	ttl     time.Duration
+	flags   map[string]Flag
+	loaded  time.Time
+	onError func(err error)
+	mu      sync.Mutex // Guards the fields above
+	loading sync.Mutex // Held while reading the store
+}
+
+// NewManager creates a manager reading flags from the store.
+func NewManager(store Store) *Manager {
+	return &Manager{store: store, ttl: DefaultCacheTTL}
+}
+
+// SetCacheTTL sets how long loaded flags are reused; zero reads the store on every evaluation.
+func (m *Manager) SetCacheTTL(ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ttl = ttl
+}
+
+// OnError sets the handler of store errors. The last flags loaded stay in use meanwhile.
+func (m *Manager) OnError(handler func(err error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onError = handler
+}
+
+// Refresh makes the next evaluation read the store again.
+func (m *Manager) Refresh() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loaded = time.Time{}
+}
+
+// Flags returns the flag definitions sorted by name.
+func (m *Manager) Flags(ctx context.Context) []Flag {
+	flags := []Flag{}
+	for _, flag := range m.load(ctx) {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+	return flags
+}
+
+// Enabled reports whether the flag is on for the subject in the context. Unknown flags are off.
+func (m *Manager) Enabled(ctx context.Context, name string) bool {
+	flag, ok := m.load(ctx)[name]
+	return ok && flag.EnabledFor(SubjectFrom(ctx))
+}
+
+// For returns the evaluation of the flags for the subject in the context, for passing to templates:
+//
+//	{{if .Features.Enabled "new_checkout"}}...{{end}}
+func (m *Manager) For(ctx context.Context) Evaluation {
+	return Evaluation{manager: m, ctx: ctx}
+}
+
+// Require returns middleware answering 404 unless every named flag is on, hiding the routes
+// of disabled features.
+func (m *Manager) Require(names ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, name := range names {
+				if !m.Enabled(r.Context(), name) {
+					http.NotFound(w, r)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// FuncMap returns template functions for conditional rendering. The feature function takes
+// the request or its context and the flag name:
+//
+//	{{if feature .Request "new_checkout"}}...{{end}}
+func (m *Manager) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"feature": func(subject interface{}, name string) (bool, error) {
+			switch s := subject.(type) {
+			case *http.Request:
+				return m.Enabled(s.Context(), name), nil
+			case context.Context:
+				return m.Enabled(s, name), nil
+			default:
+				return false, fmt.Errorf("feature expects a request or context, got %T", subject)
+			}
+		},
+	}
+}
+
+// load returns the cached flags, reading the store when the cache expired. One evaluation
+// reads the store at a time; the others keep using the previous flags meanwhile.
+func (m *Manager) load(ctx context.Context) map[string]Flag {
+	flags, fresh := m.cached()
+	if fresh {
+		return flags
+	}
+	if flags != nil && !m.loading.TryLock() {
+		return flags
+	} else if flags == nil {
+		m.loading.Lock()
+	}
+	defer m.loading.Unlock()
+	if flags, fresh := m.cached(); fresh {
+		return flags // Loaded while this evaluation waited
+	}
+
+	loaded, err := m.store.Flags(ctx)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		if m.onError != nil {
+			m.onError(err)
+		}
+		if m.flags == nil {
+			return map[string]Flag{}
+		}
+		return m.flags
+	}
+	for name, flag := range loaded {
+		flag.Name = name
+		loaded[name] = flag
+	}
+	m.flags, m.loaded = loaded, time.Now()
+	return loaded
+}
+
+// cached returns the flags loaded last and whether they are still fresh.
+func (m *Manager) cached() (map[string]Flag, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.flags, m.flags != nil && time.Since(m.loaded) < m.ttl
+}
+
+// EnabledFor reports whether the flag is on for the subject.
+func (f Flag) EnabledFor(subject Subject) bool {
+	if !f.Enabled {
+		return false
+	}
+	if (subject.User != "" && contains(f.Users, subject.User)) || (subject.Tenant != "" && contains(f.Tenants, subject.Tenant)) {
+		return true
+	}
+	if f.Percentage == nil {
+		return len(f.Users) == 0 && len(f.Tenants) == 0
+	}
+	if *f.Percentage >= 100 {
+		return true
+	}
+
+	key := subject.User
+	if key == "" {
+		key = subject.Tenant
+	}
+	if key == "" {
+		return false
+	}
+	return bucket(f.Name, key) < *f.Percentage
+}
+
+// Evaluation evaluates flags for the subject of a context.
+type Evaluation struct {
+	manager *Manager
+	ctx     context.Context
+}
+
+// Enabled reports whether the flag is on.
+func (e Evaluation) Enabled(name string) bool {
+	return e.manager.Enabled(e.ctx, name)
+}
+
+// bucket places a subject in [0, 100) for a flag, stably across processes and restarts.
+func bucket(flag, key string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(flag + ":" + key))
+	return float64(h.Sum32()%10000) / 100
+}
+
+// contains reports whether a list contains a value.
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManagerKeepsServingFlagsWhileTheStoreIsSlow(t *testing.T) {
+	release := make(chan struct{})
+	calls := 0
+	m := NewManager(StoreFunc(func(ctx context.Context) (map[string]Flag, error) {
+		calls++
+		if calls == 2 {
+			<-release // The second read hangs until the test releases it
+		}
+		return map[string]Flag{"checkout": {Enabled: true}}, nil
+	}))
+	m.SetCacheTTL(0)
+
+	if !m.Enabled(context.Background(), "checkout") {
+		t.Fatal("checkout is off, want on")
+	}
+	go m.Enabled(context.Background(), "checkout")
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan bool)
+	go func() { done <- m.Enabled(context.Background(), "checkout") }()
+	select {
+	case enabled := <-done:
+		if !enabled {
+			t.Fatal("checkout is off while the store reloads, want the previous flags")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("evaluation waited for the store")
+	}
+	close(release)
+}
+
+func TestManagerReadsTheStoreAgainAfterAnError(t *testing.T) {
+	failing := true
+	errs := 0
+	m := NewManager(StoreFunc(func(ctx context.Context) (map[string]Flag, error) {
+		if failing {
+			return nil, errors.New("database unavailable")
+		}
+		return map[string]Flag{"checkout": {Enabled: true}}, nil
+	}))
+	m.OnError(func(err error) { errs++ })
+
+	if m.Enabled(context.Background(), "checkout") || errs != 1 {
+		t.Fatalf("got %d errors, want checkout off and the error reported", errs)
+	}
+	failing = false
+	if !m.Enabled(context.Background(), "checkout") {
+		t.Fatal("checkout is off after the store recovered")
+	}
+}
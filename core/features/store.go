@@ -0,0 +1,235 @@
+package features
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ParseFlags reads flag definitions from a configuration value: a map from flag names to
+// either a boolean or a map with the keys enabled, percentage, users and tenants.
+//
+//	new_checkout: true
+//	beta_dashboard:
+//	  enabled: true
+//	  percentage: 25
+//	  tenants: [acme]
+func ParseFlags(value interface{}) (map[string]Flag, error) {
+	definitions, err := stringMap(value)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := make(map[string]Flag, len(definitions))
+	for name, definition := range definitions {
+		flag, err := parseFlag(name, definition)
+		if err != nil {
+			return nil, fmt.Errorf("flag %s: %w", name, err)
+		}
+		flags[name] = flag
+	}
+	return flags, nil
+}
+
+// parseFlag reads a single flag definition.
+func parseFlag(name string, definition interface{}) (Flag, error) {
+	if enabled, ok := definition.(bool); ok {
+		return Flag{Name: name, Enabled: enabled}, nil
+	}
+	fields, err := stringMap(definition)
+	if err != nil {
+		return Flag{}, fmt.Errorf("expected a bool or a map, got %T", definition)
+	}
+
+	flag := Flag{Name: name, Enabled: true}
+	for key, value := range fields {
+		switch key {
+		case "enabled":
+			enabled, ok := value.(bool)
+			if !ok {
+				return Flag{}, fmt.Errorf("enabled: expected a bool, got %T", value)
+			}
+			flag.Enabled = enabled
+		case "percentage":
+			var percentage float64
+			switch n := value.(type) {
+			case int:
+				percentage = float64(n)
+			case float64:
+				percentage = n
+			default:
+				return Flag{}, fmt.Errorf("percentage: expected a number, got %T", value)
+			}
+			if percentage < 0 || percentage > 100 {
+				return Flag{}, fmt.Errorf("percentage: %v is not between 0 and 100", percentage)
+			}
+			flag.Percentage = &percentage
+		case "users":
+			if flag.Users, err = stringList(value); err != nil {
+				return Flag{}, fmt.Errorf("users: %w", err)
+			}
+		case "tenants":
+			if flag.Tenants, err = stringList(value); err != nil {
+				return Flag{}, fmt.Errorf("tenants: %w", err)
+			}
+		default:
+			return Flag{}, fmt.Errorf("unknown key %q", key)
+		}
+	}
+	return flag, nil
+}
+
+// stringMap converts a decoded YAML or JSON map to a map keyed by strings.
+func stringMap(value interface{}) (map[string]interface{}, error) {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		return m, nil
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(m))
+		for key, item := range m {
+			converted[fmt.Sprint(key)] = item
+		}
+		return converted, nil
+	case nil:
+		return map[string]interface{}{}, nil
+	}
+	return nil, fmt.Errorf("expected a map, got %T", value)
+}
+
+// stringList converts a list or comma-separated string to strings.
+func stringList(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return splitList(v), nil
+	case []interface{}:
+		list := []string{}
+		for _, item := range v {
+			list = append(list, fmt.Sprint(item))
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("expected a list, got %T", value)
+}
+
+// splitList splits a comma-separated string, dropping empty items.
+func splitList(text string) []string {
+	list := []string{}
+	for _, item := range strings.Split(text, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// FileStore reads flags from a YAML or JSON file in the format of ParseFlags, re-reading
+// it when it changes. A missing file defines no flags.
+type FileStore struct {
+	path     string
+	modified time.Time
+	flags    map[string]Flag
+	mu       sync.Mutex
+}
+
+// NewFileStore creates a store reading the file at path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Flags returns the flags in the file.
+func (s *FileStore) Flags(ctx context.Context) (map[string]Flag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		return map[string]Flag{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.flags != nil && info.ModTime().Equal(s.modified) {
+		return copyFlags(s.flags), nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", s.path, err)
+	}
+	flags, err := ParseFlags(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	s.flags, s.modified = flags, info.ModTime()
+	return copyFlags(flags), nil
+}
+
+// copyFlags returns a copy of the map, so callers may modify it.
+func copyFlags(flags map[string]Flag) map[string]Flag {
+	copied := make(map[string]Flag, len(flags))
+	for name, flag := range flags {
+		copied[name] = flag
+	}
+	return copied
+}
+
+// SQLStore reads flags from a database table through database/sql, so they can be changed
+// without a deploy. Run Migrate once to create the table.
+type SQLStore struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLStore creates a store using the feature_flags table.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db, table: "feature_flags"}
+}
+
+// Migrate creates the table of the store if it doesn't exist. The percentage is NULL for
+// flags without a rollout; users and tenants are comma-separated.
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		name VARCHAR(255) PRIMARY KEY,
+		enabled SMALLINT NOT NULL DEFAULT 0,
+		percentage DOUBLE PRECISION NULL,
+		users VARCHAR(1024) NOT NULL DEFAULT '',
+		tenants VARCHAR(1024) NOT NULL DEFAULT ''
+	)`, s.table))
+	return err
+}
+
+// Flags returns the flags in the table.
+func (s *SQLStore) Flags(ctx context.Context) (map[string]Flag, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT name, enabled, percentage, users, tenants FROM %s", s.table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	flags := map[string]Flag{}
+	for rows.Next() {
+		var name, enabled, users, tenants string
+		var percentage sql.NullFloat64
+		if err := rows.Scan(&name, &enabled, &percentage, &users, &tenants); err != nil {
+			return nil, err
+		}
+		flag := Flag{Name: name, Users: splitList(users), Tenants: splitList(tenants)}
+		flag.Enabled, _ = strconv.ParseBool(enabled)
+		if percentage.Valid {
+			flag.Percentage = &percentage.Float64
+		}
+		flags[name] = flag
+	}
+	return flags, rows.Err()
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"icepeak/core/health"
+)
+
+// WithHealthEndpoints sets the paths of the liveness and readiness endpoints.
+// An empty path disables that endpoint.
+func WithHealthEndpoints(live, ready string) KernelOption {
+	return func(k *Kernel) {
+		k.healthLivePath = live
+		k.healthReadyPath = ready
+	}
+}
+
+// Health returns the registry of readiness checks of this kernel.
+func (k *Kernel) Health() *health.Registry {
+	return k.health
+}
+
+// RegisterHealthCheck adds a named readiness check.
+func (k *Kernel) RegisterHealthCheck(check health.Check) {
+	k.health.Register(check)
+}
+
+// HealthCheckTag tags services implementing health.Checker that become critical readiness
+// checks when the kernel boots:
+//
+//	core.Singleton[*Cache](services, newCache, core.WithTags(core.HealthCheckTag))
+const HealthCheckTag = "health-check"
+
+// RegisterServiceHealthCheck adds a readiness check for a service in the container
+// implementing health.Checker. The service is resolved each time the check runs.
+func (k *Kernel) RegisterServiceHealthCheck(name string, critical bool, timeout time.Duration) {
+	k.registerServiceHealthCheck(serviceKey{name: name}, critical, timeout)
+}
+
+// registerServiceHealthCheck adds a readiness check for the service with the key.
+func (k *Kernel) registerServiceHealthCheck(key serviceKey, critical bool, timeout time.Duration) {
+	k.health.Register(health.Check{
+		Name:     key.String(),
+		Critical: critical,
+		Timeout:  timeout,
+		Check: func(ctx context.Context) error {
+			service, err := k.Services.resolve(key)
+			if err != nil {
+				return err
+			}
+			checker, ok := service.(health.Checker)
+			if !ok {
+				return fmt.Errorf("service '%s' does not implement health.Checker", key)
+			}
+			return checker.HealthCheck(ctx)
+		},
+	})
+}
+
+// registerTaggedHealthChecks adds a critical readiness check for each service tagged
+// HealthCheckTag.
+func (k *Kernel) registerTaggedHealthChecks() {
+	for _, key := range k.Services.tagged(HealthCheckTag) {
+		k.registerServiceHealthCheck(key, true, 0)
+	}
+}
+
+// registerHealthRoutes registers the liveness and readiness endpoints.
+func (k *Kernel) registerHealthRoutes() {
+	if k.healthLivePath != "" {
+		k.Router.Get(k.healthLivePath, k.health.LiveHandler().ServeHTTP)
+	}
+	if k.healthReadyPath != "" {
+		k.Router.Get(k.healthReadyPath, k.health.ReadyHandler().ServeHTTP)
+	}
+}
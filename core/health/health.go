@@ -0,0 +1,190 @@
+// Package health runs liveness and readiness checks and serves their results as JSON.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout is the timeout of checks that don't set one.
+const DefaultTimeout = 5 * time.Second
+
+// Statuses reported for checks and for the overall result.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // A non-critical check failed
+	StatusFailing  = "failing"  // A critical check failed or the application is shutting down
+)
+
+// Check is a named readiness check.
+type Check struct {
+	Name     string
+	Check    func(ctx context.Context) error
+	Timeout  time.Duration
+	Critical bool // A failing critical check makes the application not ready
+}
+
+// Checker is implemented by services that can check their own health.
+type Checker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of all checks.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Registry holds the readiness checks of an application.
+type Registry struct {
+	checks       map[string]Check
+	shuttingDown atomic.Bool
+	mu           sync.RWMutex
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{checks: make(map[string]Check)}
+}
+
+// Register adds a check, replacing any check with the same name.
+func (r *Registry) Register(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultTimeout
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[check.Name] = check
+}
+
+// SetShuttingDown makes readiness fail while the application drains requests.
+func (r *Registry) SetShuttingDown(shuttingDown bool) {
+	r.shuttingDown.Store(shuttingDown)
+}
+
+// ShuttingDown reports whether the application is shutting down.
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Run runs every check concurrently and collects the results.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]Check, 0, len(r.checks))
+	for _, check := range r.checks {
+		checks = append(checks, check)
+	}
+	r.mu.RUnlock()
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Name < checks[j].Name
+	})
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Status == StatusOK {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusFailing
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	if r.ShuttingDown() {
+		report.Status = StatusFailing
+	}
+	return report
+}
+
+// run runs a check, reporting it as failed once its timeout passes even if it ignores its context.
+func run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("panic: %v", recovered)
+			}
+		}()
+		done <- check.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %v", check.Timeout)
+		}
+	}
+
+	result := Result{
+		Name:      check.Name,
+		Status:    StatusOK,
+		Critical:  check.Critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// LiveHandler reports that the process is up and able to serve requests.
+func (r *Registry) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, Report{Status: StatusOK, Checks: []Result{}})
+	})
+}
+
+// ReadyHandler runs the checks and responds 503 when a critical check fails or the application is shutting down.
+func (r *Registry) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Run(req.Context())
+		status := http.StatusOK
+		if report.Status == StatusFailing {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, report)
+	})
+}
+
+// writeJSON writes a report as JSON.
+func writeJSON(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,240 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+var (
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+	containerType = reflect.TypeOf((*ServiceContainer)(nil))
+)
+
+// Provide registers a constructor whose parameters are resolved from the container by type,
+// creating a new instance on every resolution. The constructor returns the service, and
+// optionally an error, and is bound to the type of the service:
+//
+//	services.Provide(func(log core.Logger, db *sql.DB) (*UserRepo, error) {
+//		return &UserRepo{log: log, db: db}, nil
+//	})
+//
+// A *ServiceContainer parameter receives the container itself.
+func (sc *ServiceContainer) Provide(constructor interface{}, options ...BindOption) error {
+	return sc.provide(constructor, LifetimeTransient, options)
+}
+
+// ProvideSingleton registers a constructor like Provide whose instance is created once and shared.
+func (sc *ServiceContainer) ProvideSingleton(constructor interface{}, options ...BindOption) error {
+	return sc.provide(constructor, LifetimeSingleton, options)
+}
+
+// provide checks the constructor and binds it to its result type.
+func (sc *ServiceContainer) provide(constructor interface{}, lifetime Lifetime, options []BindOption) error {
+	fn := reflect.ValueOf(constructor)
+	if err := checkConstructor(fn); err != nil {
+		return err
+	}
+	settings := newBindOptions(options)
+	key := serviceKey{typ: fn.Type().Out(0), name: settings.name}
+	sc.bind(key, func(c *ServiceContainer) (interface{}, error) {
+		results, err := c.invoke(fn, nil, true)
+		if err != nil {
+			return nil, err
+		}
+		return results[0].Interface(), nil
+	}, lifetime, settings.tags...)
+
+	dependencies := []serviceKey{}
+	for i := 0; i < fn.Type().NumIn(); i++ {
+		if param := fn.Type().In(i); param != containerType {
+			dependencies = append(dependencies, serviceKey{typ: param})
+		}
+	}
+	sc.dependsOn(key, dependencies...)
+	return nil
+}
+
+// dependsOn records the services a service is created from, for Describe and WriteGraph.
+func (sc *ServiceContainer) dependsOn(key serviceKey, dependencies ...serviceKey) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	sc.dependencies[key] = dependencies
+}
+
+// checkConstructor reports why a value is not a usable constructor.
+func checkConstructor(fn reflect.Value) error {
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return fmt.Errorf("constructor must be a function, got %s", describeValue(fn))
+	}
+	t := fn.Type()
+	if t.IsVariadic() {
+		return fmt.Errorf("constructor %s must not be variadic", t)
+	}
+	switch {
+	case t.NumOut() == 1 && t.Out(0) != errorType:
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+	default:
+		return fmt.Errorf("constructor %s must return a service, optionally followed by an error", t)
+	}
+	return nil
+}
+
+// Call invokes fn with its parameters resolved from the container by type, returning its
+// results without a trailing error, which is returned instead if it is not nil. Arguments
+// passed to Call fill the parameters they are assignable to first, in order, so handlers
+// can receive values only known at call time:
+//
+//	services.Call(func(w http.ResponseWriter, r *http.Request, repo *UserRepo) { ... }, w, r)
+func (sc *ServiceContainer) Call(fn interface{}, args ...interface{}) ([]interface{}, error) {
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func || value.IsNil() {
+		return nil, fmt.Errorf("Call expects a function, got %s", describeValue(value))
+	}
+	if value.Type().IsVariadic() {
+		return nil, fmt.Errorf("Call does not support variadic function %s", value.Type())
+	}
+
+	results, err := sc.invoke(value, args, true)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(results))
+	for i, result := range results {
+		values[i] = result.Interface()
+	}
+	return values, nil
+}
+
+// Handler adapts a function with injected parameters to an http.HandlerFunc. The function
+// may take the response writer and request alongside the services it asks for, resolved
+// from the request scope; a non-nil error it returns, or a failure to resolve its services,
+// answers 500:
+//
+//	router.Get("/users", kernel.Handler(func(w http.ResponseWriter, r *http.Request, repo *UserRepo) error {
+//		...
+//	}))
+func (k *Kernel) Handler(fn interface{}) http.HandlerFunc {
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func || value.Type().IsVariadic() {
+		panic(fmt.Sprintf("Handler expects a function that is not variadic, got %s", describeValue(value)))
+	}
+	errorHandler := k.Router.ErrorHandler()
+	return func(w http.ResponseWriter, r *http.Request) {
+		services := RequestScope(r)
+		if services == nil {
+			services = k.Services
+		}
+		if _, err := services.invoke(value, []interface{}{w, r}, false); err != nil {
+			errorHandler.HandleError(w, r, http.StatusInternalServerError, err)
+		}
+	}
+}
+
+// injectController sets the dependencies of a controller struct from the request scope, for
+// the controllers routed with Router.Action.
+func (k *Kernel) injectController(r *http.Request, controller interface{}) error {
+	services := RequestScope(r)
+	if services == nil {
+		services = k.Services
+	}
+	return services.AutoResolve(controller)
+}
+
+// invoke calls fn with the given arguments and services, dropping a trailing error result
+// after returning it. With strict set, every argument must fill a parameter.
+func (sc *ServiceContainer) invoke(fn reflect.Value, args []interface{}, strict bool) ([]reflect.Value, error) {
+	t := fn.Type()
+	in, err := sc.arguments(t, args, strict)
+	if err != nil {
+		return nil, err
+	}
+
+	results := fn.Call(in)
+	if n := len(results); n > 0 && t.Out(n-1) == errorType {
+		if err, _ := results[n-1].Interface().(error); err != nil {
+			return nil, err
+		}
+		results = results[:n-1]
+	}
+	return results, nil
+}
+
+// arguments builds the parameters of a function type from explicit arguments, then services.
+func (sc *ServiceContainer) arguments(t reflect.Type, args []interface{}, strict bool) ([]reflect.Value, error) {
+	used := make([]bool, len(args))
+	in := make([]reflect.Value, t.NumIn())
+
+parameters:
+	for i := range in {
+		param := t.In(i)
+		for j, arg := range args {
+			if used[j] {
+				continue
+			}
+			if arg == nil {
+				if canBeNil(param) {
+					used[j], in[i] = true, reflect.Zero(param)
+					continue parameters
+				}
+				continue
+			}
+			if reflect.TypeOf(arg).AssignableTo(param) {
+				used[j], in[i] = true, reflect.ValueOf(arg)
+				continue parameters
+			}
+		}
+
+		value, err := sc.resolveType(param)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %d (%s) of %s: %w", i+1, param, t, err)
+		}
+		in[i] = value
+	}
+
+	for j, arg := range args {
+		if strict && !used[j] {
+			return nil, fmt.Errorf("argument %d (%T) matches no parameter of %s", j+1, arg, t)
+		}
+	}
+	return in, nil
+}
+
+// resolveType resolves the service bound to a type as a value of that type.
+func (sc *ServiceContainer) resolveType(t reflect.Type) (reflect.Value, error) {
+	if t == containerType {
+		return reflect.ValueOf(sc), nil
+	}
+	service, err := sc.resolve(serviceKey{typ: t})
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if service == nil {
+		return reflect.Zero(t), nil
+	}
+	value := reflect.ValueOf(service)
+	if !value.Type().AssignableTo(t) {
+		return reflect.Value{}, fmt.Errorf("service is a %s, not a %s", value.Type(), t)
+	}
+	return value, nil
+}
+
+// canBeNil reports whether nil is a valid value of the type.
+func canBeNil(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return true
+	}
+	return false
+}
+
+// describeValue names the type of a value for error messages.
+func describeValue(value reflect.Value) string {
+	if !value.IsValid() {
+		return "nil"
+	}
+	if value.Kind() == reflect.Func && value.IsNil() {
+		return "a nil " + value.Type().String()
+	}
+	return value.Type().String()
+}
@@ -1,116 +1,445 @@
 package core
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"icepeak/core/events"
+	"icepeak/core/features"
+	"icepeak/core/health"
+	"icepeak/core/queue"
 	"icepeak/core/routing"
-
-	"github.com/joho/godotenv"
-	"gopkg.in/yaml.v2"
+	"icepeak/core/schedule"
 )
 
 // Kernel is the core of the Icepeak framework
 type Kernel struct {
 	Router     *routing.Router
 	Middleware []func(http.Handler) http.Handler
-	Config     map[string]interface{}
+	Config     map[string]interface{} // Replaced on reload; read it with ConfigValue or ConfigSnapshot
 	Services   *ServiceContainer
+
+	configDir string
+	envFile   string
+	logger    Logger
+	providers []ServiceProvider
+	booted    bool
+	verify    bool // Verify the service container before serving
+
+	schemas         []ConfigSchema
+	configErr       error
+	configSections  map[string][]string // YAML sections setting each key, see readConfiguration
+	configMu        sync.RWMutex
+	dotenv          map[string]string    // Values read from the .env file
+	configListeners []func(ConfigChange) // Subscribers notified after a reload
+	reloadInterval  time.Duration
+
+	listeners []Listener
+	events    *events.Dispatcher
+
+	scheduler    *schedule.Scheduler
+	runScheduler bool
+	queue        *queue.Queue
+	features     *features.Manager
+	featureStore features.Store
+
+	health          *health.Registry
+	healthLivePath  string
+	healthReadyPath string
 }
 
-var kernelInstance *Kernel
+// KernelOption configures a Kernel during construction.
+type KernelOption func(*Kernel)
 
-// NewKernel creates a new instance of the Kernel
-func NewKernel() *Kernel {
-	if kernelInstance == nil {
-		kernelInstance = &Kernel{
-			Router:     routing.NewRouter(),
-			Middleware: []func(http.Handler) http.Handler{},
-			Config:     make(map[string]interface{}),
-			Services:   NewServiceContainer(),
-		}
-		kernelInstance.loadEnvironment()
-		kernelInstance.loadConfiguration()
-		kernelInstance.registerDefaultServices()
+// WithConfigDir sets the directory the kernel loads its YAML configuration from.
+func WithConfigDir(dir string) KernelOption {
+	return func(k *Kernel) {
+		k.configDir = dir
 	}
-	return kernelInstance
 }
 
-// GetKernel returns the current kernel instance
-func GetKernel() *Kernel {
-	return kernelInstance
+// WithEnvFile sets the .env file the kernel loads environment variables from.
+func WithEnvFile(path string) KernelOption {
+	return func(k *Kernel) {
+		k.envFile = path
+	}
 }
 
-// loadEnvironment loads environment variables from the .env file
-func (k *Kernel) loadEnvironment() {
-	err := godotenv.Load()
-	if err != nil {
-		fmt.Println("Error loading .env file")
+// WithLogger registers the given logger instead of the default file logger.
+func WithLogger(logger Logger) KernelOption {
+	return func(k *Kernel) {
+		k.logger = logger
 	}
 }
 
-// loadConfiguration loads the configuration from YAML files
-func (k *Kernel) loadConfiguration() {
-	data, err := os.ReadFile("config/view.yaml")
-	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
-		return
+// WithServiceVerification sets whether StartServer runs Services.Verify before serving,
+// refusing to start on missing dependencies, cycles or failing singletons. It is off by default.
+func WithServiceVerification(enabled bool) KernelOption {
+	return func(k *Kernel) {
+		k.verify = enabled
 	}
-	err = yaml.Unmarshal(data, &k.Config)
-	if err != nil {
-		fmt.Printf("Error parsing configuration: %v\n", err)
+}
+
+// defaultKernel backs the legacy GetKernel accessor.
+var (
+	defaultKernel     *Kernel
+	defaultKernelLock sync.Mutex
+)
+
+// NewKernel creates a new, independent instance of the Kernel
+func NewKernel(options ...KernelOption) *Kernel {
+	k := &Kernel{
+		Router:     routing.NewRouter(),
+		Middleware: []func(http.Handler) http.Handler{},
+		Config:     make(map[string]interface{}),
+		Services:   NewServiceContainer(),
+		configDir:  "config",
+		envFile:    ".env",
+
+		reloadInterval: 2 * time.Second,
+		events:         events.NewDispatcher(),
+		scheduler:      schedule.NewScheduler(),
+		runScheduler:   true,
+		queue:          queue.New(nil),
+
+		health:          health.NewRegistry(),
+		healthLivePath:  "/health/live",
+		healthReadyPath: "/health/ready",
 	}
+	for _, option := range options {
+		option(k)
+	}
+
+	k.loadEnvironment()
+	k.Router.SetDevMode(k.Environment() == "development")
+	k.loadConfiguration()
+	k.registerDefaultConfigSchemas()
+	k.validateConfiguration()
+	k.registerDefaultServices()
+	k.Router.SetInjector(k.injectController)
+	k.registerEventErrorHandler()
+	k.configureScheduler()
+	k.configureQueue()
+	k.configureFeatures()
+	k.registerConfigListeners()
+	k.registerDefaultMiddlewareGroups()
+	k.registerHealthRoutes()
+
+	// The first kernel created serves legacy callers of GetKernel
+	defaultKernelLock.Lock()
+	if defaultKernel == nil {
+		defaultKernel = k
+	}
+	defaultKernelLock.Unlock()
+	return k
+}
+
+// SetDefaultKernel sets the kernel returned by GetKernel.
+func SetDefaultKernel(k *Kernel) {
+	defaultKernelLock.Lock()
+	defer defaultKernelLock.Unlock()
+	defaultKernel = k
 }
 
-// RegisterMiddleware registers middleware to be applied to all routes
+// GetKernel returns the kernel set with SetDefaultKernel or, failing that, the first kernel
+// created.
+//
+// Deprecated: GetKernel exists only for legacy code. Pass the kernel, or the
+// services it provides, to the code that needs them instead.
+func GetKernel() *Kernel {
+	defaultKernelLock.Lock()
+	defer defaultKernelLock.Unlock()
+	return defaultKernel
+}
+
+// Environment returns the application environment from APP_ENV or ENVIRONMENT, defaulting to production.
+func (k *Kernel) Environment() string {
+	if env := k.env("APP_ENV"); env != "" {
+		return env
+	}
+	if env := k.env("ENVIRONMENT"); env != "" {
+		return env
+	}
+	return "production"
+}
+
+// RegisterMiddleware registers middleware to be applied to all routes.
+// Middleware runs in registration order: the first registered is the outermost.
 func (k *Kernel) RegisterMiddleware(middleware func(http.Handler) http.Handler) {
 	k.Middleware = append(k.Middleware, middleware)
 }
 
+// AliasMiddleware registers route middleware under a name usable with Router.Use and Route.Use.
+func (k *Kernel) AliasMiddleware(name string, middleware func(http.Handler) http.Handler) {
+	k.Router.Middleware().Alias(name, middleware)
+}
+
+// AliasMiddlewareFactory registers parameterized route middleware, used as "name:param1,param2".
+func (k *Kernel) AliasMiddlewareFactory(name string, factory routing.MiddlewareFactory) {
+	k.Router.Middleware().AliasFactory(name, factory)
+}
+
+// MiddlewareGroup registers a named group of middleware aliases, e.g. "web" or "api".
+func (k *Kernel) MiddlewareGroup(name string, members ...string) {
+	k.Router.Middleware().Group(name, members...)
+}
+
+// MiddlewarePriority sets the order named middleware runs in, regardless of registration order.
+func (k *Kernel) MiddlewarePriority(names ...string) {
+	k.Router.Middleware().SetPriority(names...)
+}
+
 // RegisterDefaultServices registers default services in the service container.
 func (k *Kernel) registerDefaultServices() {
+	k.Services.RegisterSingleton("health", func() interface{} {
+		return k.health
+	})
+	k.Services.RegisterSingleton("events", func() interface{} {
+		return k.events
+	})
+	k.Services.RegisterSingleton("schedule", func() interface{} {
+		return k.scheduler
+	})
+	k.Services.RegisterSingleton("queue", func() interface{} {
+		return k.queue
+	})
+	k.Services.RegisterSingleton("features", func() interface{} {
+		return k.features
+	})
+
+	Singleton[*Kernel](k.Services, func(c *ServiceContainer) (*Kernel, error) {
+		return k, nil
+	})
+	bindService[*health.Registry](k.Services, "health")
+	bindService[*events.Dispatcher](k.Services, "events")
+	bindService[*schedule.Scheduler](k.Services, "schedule")
+	bindService[*queue.Queue](k.Services, "queue")
+	bindService[*features.Manager](k.Services, "features")
+	bindService[Logger](k.Services, "logger")
+
+	if k.logger != nil {
+		k.Services.Instance("logger", k.logger) // Owned by the caller, who closes it
+		return
+	}
+
 	k.Services.Register("logger", func() interface{} {
-		return NewDefaultLogger("DEBUG", "file") // Log level and output can be configured
+		return NewDefaultLogger(k.ConfigString("LOG_LEVEL", "DEBUG"), "file")
 	}, true) // Registering logger as a singleton
 }
 
+// registerDefaultMiddlewareGroups registers the empty "web" and "api" groups applications fill in.
+func (k *Kernel) registerDefaultMiddlewareGroups() {
+	k.MiddlewareGroup("web")
+	k.MiddlewareGroup("api")
+}
+
+// Logger resolves the logger service of this kernel.
+func (k *Kernel) Logger() Logger {
+	logger, err := Make[Logger](k.Services)
+	if err != nil {
+		fmt.Printf("Error resolving logger service: %v\n", err)
+		return nil
+	}
+	return logger
+}
+
+// database resolves the *sql.DB bound by type or, failing that, registered as the "db" service.
+func (k *Kernel) database() (*sql.DB, error) {
+	if HasType[*sql.DB](k.Services) {
+		return Make[*sql.DB](k.Services)
+	}
+	service, err := k.Services.Resolve("db")
+	if err != nil {
+		return nil, err
+	}
+	db, ok := service.(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("the 'db' service is a %T, not a *sql.DB", service)
+	}
+	return db, nil
+}
+
 // HandleRequest manages the request lifecycle
 func (k *Kernel) HandleRequest(w http.ResponseWriter, req *http.Request) {
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		k.Router.ServeHTTP(w, req)
 	}))
 
-	// Apply all registered middleware
-	for _, mw := range k.Middleware {
-		handler = mw(handler)
+	// Apply all registered middleware, wrapping in reverse so the first registered runs first
+	for i := len(k.Middleware) - 1; i >= 0; i-- {
+		handler = k.Middleware[i](handler)
+	}
+	handler = k.ScopeMiddleware()(handler) // Outermost, so every middleware sees the request scope
+
+	if !k.events.HasListeners(EventRequestHandled) {
+		handler.ServeHTTP(w, req)
+		return
 	}
 
-	handler.ServeHTTP(w, req)
+	start := time.Now()
+	recorder := &statusRecorder{ResponseWriter: w}
+	handler.ServeHTTP(recorder, req)
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	k.Dispatch(req.Context(), RequestHandled{Request: req, Status: recorder.status, Duration: time.Since(start)})
 }
 
-// StartServer starts the HTTP server
-func (k *Kernel) StartServer(address string) {
-	// Resolve the logger service
-	logger, err := k.Services.Resolve("logger")
+// ListenFDEnv names the environment variable holding an inherited listener file descriptor.
+// The development server sets it so restarted processes keep serving on the same socket.
+const ListenFDEnv = "ICEPEAK_LISTEN_FD"
+
+// shutdownTimeout bounds how long in-flight requests may take to finish on shutdown.
+const shutdownTimeout = 10 * time.Second
+
+// StartServer serves HTTP on the given addresses and on the listeners added with Listen,
+// and shuts every server down gracefully on SIGINT or SIGTERM.
+func (k *Kernel) StartServer(addresses ...string) {
+	logger := k.Logger()
+	if logger == nil {
+		return
+	}
+
+	// Boot the service providers before serving requests, refusing invalid configuration
+	if err := k.Boot(); err != nil {
+		logger.Error(fmt.Sprintf("Refusing to start: %v", err))
+		return
+	}
+	if k.verify {
+		if err := k.Services.Verify(); err != nil {
+			logger.Error(fmt.Sprintf("Refusing to start, the service container is invalid:\n%v", err))
+			return
+		}
+	}
+
+	// Sockets passed by systemd take the place of the addresses
+	sockets, err := socketActivation()
 	if err != nil {
-		fmt.Printf("Error resolving logger service: %v\n", err)
+		logger.Error(fmt.Sprintf("Error starting server: %v", err))
+		return
+	}
+	addresses, activated, err := activate(addresses, k.listeners, sockets)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error starting server: %v", err))
 		return
 	}
 
-	// Cast the resolved service to *DefaultLogger
-	defaultLogger, ok := logger.(*DefaultLogger)
-	if !ok {
-		fmt.Printf("Error casting logger service to *DefaultLogger\n")
+	listeners := []Listener{}
+	for i, address := range addresses {
+		// The development server hands over the socket of the first address
+		if fd := os.Getenv(ListenFDEnv); fd != "" && i == 0 {
+			address = "fd://" + fd
+		}
+		listeners = append(listeners, Listener{Address: address})
+	}
+	listeners = append(listeners, activated...)
+	if len(listeners) == 0 {
+		logger.Error("Error starting server: no addresses to listen on")
 		return
 	}
 
-	// Log server start
-	defaultLogger.Info(fmt.Sprintf("Server running at %s", address))
+	// Open every listener before serving so a bad address fails the whole start
+	opened := []net.Listener{}
+	for _, listener := range listeners {
+		l, err := listener.open()
+		if err != nil {
+			for _, l := range opened {
+				l.Close()
+			}
+			logger.Error(fmt.Sprintf("Error starting server on %s: %v", listener.Address, err))
+			return
+		}
+		opened = append(opened, l)
+	}
 
-	// Start the HTTP server
-	err = http.ListenAndServe(address, http.HandlerFunc(k.HandleRequest))
-	if err != nil {
-		defaultLogger.Error(fmt.Sprintf("Error starting server: %v", err))
+	// Reload the configuration when its files change
+	stopWatching := func() {}
+	if k.reloadInterval > 0 {
+		stopWatching = k.WatchConfig(k.reloadInterval)
+	}
+
+	// Run scheduled tasks until the servers have shut down
+	stopScheduler := k.startScheduler()
+
+	servers := make([]*http.Server, len(listeners))
+	for i, listener := range listeners {
+		servers[i] = &http.Server{Handler: listener.handler(k)}
+	}
+
+	shutdown := make(chan struct{})
+	stop := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+		case <-stop:
+		}
+
+		// Fail readiness first so load balancers stop routing new requests here
+		logger.Info("Server shutting down")
+		k.health.SetShuttingDown(true)
+		k.Dispatch(context.Background(), ServerShuttingDown{})
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		var wg sync.WaitGroup
+		for _, server := range servers {
+			wg.Add(1)
+			go func(server *http.Server) {
+				defer wg.Done()
+				if err := server.Shutdown(ctx); err != nil {
+					logger.Error(fmt.Sprintf("Error shutting down server: %v", err))
+				}
+			}(server)
+		}
+		wg.Wait()
+	}()
+
+	// Start an HTTP server per listener; one failing stops the others
+	var once sync.Once
+	var wg sync.WaitGroup
+	for i, l := range opened {
+		logger.Info(fmt.Sprintf("Server running at %s", describeListener(l)))
+		wg.Add(1)
+		go func(server *http.Server, l net.Listener) {
+			defer wg.Done()
+			if err := server.Serve(l); err != http.ErrServerClosed {
+				logger.Error(fmt.Sprintf("Error serving on %s: %v", describeListener(l), err))
+				once.Do(func() { close(stop) })
+			}
+		}(servers[i], l)
+	}
+	wg.Wait()
+
+	// Wait for in-flight requests, scheduled tasks, reloads and asynchronous event listeners
+	<-shutdown
+	stopScheduler()
+	stopWatching()
+	k.events.Wait()
+
+	// Release the resources of services last, as the code above may still use them
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := k.Services.Shutdown(ctx); err != nil {
+		fmt.Printf("Error disposing services: %v\n", err)
+	}
+}
+
+// describeListener formats the address of a listener for logs.
+func describeListener(l net.Listener) string {
+	addr := l.Addr()
+	if addr.Network() == "unix" {
+		return "unix://" + addr.String()
 	}
+	return addr.String()
 }
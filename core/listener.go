@@ -0,0 +1,182 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Listener describes an address the server accepts connections on.
+//
+// Address is a TCP address such as ":8080" or "tcp://127.0.0.1:9090", a Unix socket
+// such as "unix:///run/icepeak.sock", an inherited file descriptor such as "fd://3", or a
+// socket passed by systemd socket activation under its FileDescriptorName, such as
+// "systemd://admin".
+type Listener struct {
+	Address    string
+	Mode       os.FileMode                       // Permissions of a Unix socket, e.g. 0660
+	Handler    http.Handler                      // Handler of this listener; the kernel's router and middleware if nil
+	Middleware []func(http.Handler) http.Handler // Middleware wrapped around Handler, the first one outermost
+}
+
+// WithListener adds a listener the server accepts connections on.
+func WithListener(listener Listener) KernelOption {
+	return func(k *Kernel) {
+		k.listeners = append(k.listeners, listener)
+	}
+}
+
+// Listen adds a listener the server accepts connections on.
+func (k *Kernel) Listen(listener Listener) {
+	k.listeners = append(k.listeners, listener)
+}
+
+// handler returns the handler serving the requests of a listener.
+func (l Listener) handler(k *Kernel) http.Handler {
+	handler := l.Handler
+	if handler == nil {
+		handler = http.HandlerFunc(k.HandleRequest)
+	}
+	for i := len(l.Middleware) - 1; i >= 0; i-- {
+		handler = l.Middleware[i](handler)
+	}
+	return handler
+}
+
+// listenFDsStart is the first file descriptor systemd passes sockets from.
+const listenFDsStart = 3
+
+// activatedSocket is a socket passed by systemd socket activation.
+type activatedSocket struct {
+	fd   int
+	name string // FileDescriptorName of the socket unit, empty if not given
+}
+
+// socketActivation returns the sockets systemd passed to the process through LISTEN_PID,
+// LISTEN_FDS and LISTEN_FDNAMES, then unsets the variables so child processes don't take
+// the sockets for theirs.
+func socketActivation() ([]activatedSocket, error) {
+	pid, fds, names := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getenv("LISTEN_FDNAMES")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	// The variables may have been inherited from a parent that was activated
+	if fds == "" || pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS '%s'", fds)
+	}
+
+	sockets := make([]activatedSocket, n)
+	fdNames := strings.Split(names, ":")
+	for i := range sockets {
+		sockets[i].fd = listenFDsStart + i
+		if i < len(fdNames) {
+			sockets[i].name = fdNames[i]
+		}
+	}
+	return sockets, nil
+}
+
+// activate maps listeners addressed as "systemd://name" to the sockets systemd passed under
+// that name. The other sockets replace the addresses given to StartServer, so the unit
+// decides where the server listens.
+func activate(addresses []string, listeners []Listener, sockets []activatedSocket) ([]string, []Listener, error) {
+	claimed := make([]bool, len(sockets))
+	activated := make([]Listener, len(listeners))
+	for i, listener := range listeners {
+		activated[i] = listener
+		network, name := parseListenAddress(listener.Address)
+		if network != "systemd" {
+			continue
+		}
+		found := false
+		for j, socket := range sockets {
+			if socket.name == name && !claimed[j] {
+				claimed[j], found = true, true
+				activated[i].Address = "fd://" + strconv.Itoa(socket.fd)
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("no socket named '%s' was passed by systemd", name)
+		}
+	}
+
+	if len(sockets) == 0 {
+		return addresses, activated, nil
+	}
+	addresses = nil
+	for j, socket := range sockets {
+		if !claimed[j] {
+			addresses = append(addresses, "fd://"+strconv.Itoa(socket.fd))
+		}
+	}
+	return addresses, activated, nil
+}
+
+// open opens the network listener for the address.
+func (l Listener) open() (net.Listener, error) {
+	network, address := parseListenAddress(l.Address)
+	switch network {
+	case "fd":
+		n, err := strconv.Atoi(address)
+		if err != nil || n < 3 {
+			return nil, fmt.Errorf("invalid file descriptor '%s'", address)
+		}
+		file := os.NewFile(uintptr(n), "listener-"+address)
+		defer file.Close()
+		return net.FileListener(file)
+	case "unix":
+		return listenUnix(address, l.Mode)
+	default:
+		return net.Listen(network, address)
+	}
+}
+
+// parseListenAddress splits an address into its network and the address within it.
+func parseListenAddress(address string) (string, string) {
+	for _, network := range []string{"tcp", "tcp4", "tcp6", "unix", "fd", "systemd"} {
+		if strings.HasPrefix(address, network+"://") {
+			return network, strings.TrimPrefix(address, network+"://")
+		}
+	}
+	return "tcp", address
+}
+
+// listenUnix listens on a Unix socket, replacing a stale socket file left by a previous run.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another process", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+	return listener, nil
+}
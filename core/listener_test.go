@@ -0,0 +1,67 @@
+package core
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestSocketActivationReadsAndUnsetsTheEnvironment(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "2")
+	t.Setenv("LISTEN_FDNAMES", "web:admin")
+
+	sockets, err := socketActivation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []activatedSocket{{fd: 3, name: "web"}, {fd: 4, name: "admin"}}
+	if !reflect.DeepEqual(sockets, want) {
+		t.Fatalf("sockets are %+v, want %+v", sockets, want)
+	}
+	for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		if _, set := os.LookupEnv(name); set {
+			t.Errorf("%s is still set", name)
+		}
+	}
+}
+
+func TestSocketActivationIgnoresSocketsOfAnotherProcess(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+
+	if sockets, err := socketActivation(); err != nil || sockets != nil {
+		t.Fatalf("got %+v, %v, want no sockets", sockets, err)
+	}
+	if _, set := os.LookupEnv("LISTEN_FDS"); set {
+		t.Error("LISTEN_FDS is still set")
+	}
+}
+
+func TestActivatedSocketsReplaceAddresses(t *testing.T) {
+	sockets := []activatedSocket{{fd: 3, name: "web"}, {fd: 4, name: "admin"}, {fd: 5}}
+	listeners := []Listener{{Address: "systemd://admin"}, {Address: "127.0.0.1:9090"}}
+
+	addresses, activated, err := activate([]string{":8080"}, listeners, sockets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"fd://3", "fd://5"}; !reflect.DeepEqual(addresses, want) {
+		t.Errorf("addresses are %v, want %v", addresses, want)
+	}
+	if activated[0].Address != "fd://4" || activated[1].Address != "127.0.0.1:9090" {
+		t.Errorf("listeners are %+v, want the admin socket and the TCP address", activated)
+	}
+	if listeners[0].Address != "systemd://admin" {
+		t.Error("the listeners of the kernel were modified")
+	}
+
+	// Without socket activation, the addresses stay and named sockets are an error
+	if addresses, _, err := activate([]string{":8080"}, nil, nil); err != nil || !reflect.DeepEqual(addresses, []string{":8080"}) {
+		t.Errorf("got %v, %v without activation, want the addresses", addresses, err)
+	}
+	if _, _, err := activate(nil, listeners, nil); err == nil {
+		t.Error("a systemd listener without activation succeeded, want an error")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync"
 	"time"
 )
 
@@ -20,15 +21,31 @@ type Logger interface {
 	LogRequest(r *http.Request, start time.Time)
 }
 
+// LogLevels lists the supported log levels, from most to least verbose.
+var LogLevels = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// logLevelRank returns the position of a level in LogLevels; unknown levels rank as INFO.
+func logLevelRank(level string) int {
+	for i, known := range LogLevels {
+		if level == known {
+			return i
+		}
+	}
+	return 1
+}
+
 // DefaultLogger provides a basic logger implementation.
 type DefaultLogger struct {
 	logger *log.Logger
 	level  string
+	file   *os.File // Log file, closed by Close
+	mu     sync.RWMutex
 }
 
 // NewDefaultLogger creates a new instance of DefaultLogger with a given log level and output.
 func NewDefaultLogger(level string, output string) *DefaultLogger {
 	var out io.Writer
+	var file *os.File
 
 	// Create log directory and file path
 	logDir := "./storage/logs"
@@ -41,7 +58,7 @@ func NewDefaultLogger(level string, output string) *DefaultLogger {
 		out = os.Stdout // Fallback to stdout if directory creation fails
 	} else {
 		// Open or create the log file
-		file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+		file, err = os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
 		if err != nil {
 			fmt.Printf("Could not open log file: %v\n", err)
 			out = os.Stdout // Fallback to stdout if file creation fails
@@ -54,24 +71,56 @@ func NewDefaultLogger(level string, output string) *DefaultLogger {
 	return &DefaultLogger{
 		logger: log.New(out, "", log.LstdFlags),
 		level:  level,
+		file:   file,
+	}
+}
+
+// Close closes the log file. Messages logged afterwards only reach stdout.
+func (l *DefaultLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
 	}
+	l.logger.SetOutput(os.Stdout)
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// SetLevel changes the minimum level of logged messages.
+func (l *DefaultLogger) SetLevel(level string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+// enabled reports whether messages of the level at the given rank are logged.
+func (l *DefaultLogger) enabled(rank int) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return logLevelRank(l.level) <= rank
 }
 
 // Debug logs a debug message.
 func (l *DefaultLogger) Debug(msg string) {
-	if l.level == "DEBUG" {
+	if l.enabled(0) {
 		l.logger.Println("[DEBUG]", msg)
 	}
 }
 
 // Info logs an informational message.
 func (l *DefaultLogger) Info(msg string) {
-	l.logger.Println("[INFO]", msg)
+	if l.enabled(1) {
+		l.logger.Println("[INFO]", msg)
+	}
 }
 
 // Warn logs a warning message.
 func (l *DefaultLogger) Warn(msg string) {
-	l.logger.Println("[WARN]", msg)
+	if l.enabled(2) {
+		l.logger.Println("[WARN]", msg)
+	}
 }
 
 // Error logs an error message.
@@ -0,0 +1,244 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaintenanceFile is the state file whose presence puts the application in maintenance mode.
+const MaintenanceFile = "storage/framework/down"
+
+// maintenanceCookie holds the bypass token of clients that visited the secret path.
+const maintenanceCookie = "icepeak_maintenance"
+
+// MaintenanceState is the content of the maintenance state file.
+type MaintenanceState struct {
+	Time    int64    `json:"time"`              // Unix time maintenance started
+	Retry   int      `json:"retry,omitempty"`   // Seconds sent in the Retry-After header
+	Secret  string   `json:"secret,omitempty"`  // Path segment that sets the bypass cookie
+	Allow   []string `json:"allow,omitempty"`   // IP addresses or CIDR ranges let through
+	Message string   `json:"message,omitempty"` // Message shown to clients
+}
+
+// ReadMaintenanceState reads the state file, returning nil if the application is up.
+func ReadMaintenanceState(path string) (*MaintenanceState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := &MaintenanceState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// WriteMaintenanceState writes the state file, putting the application in maintenance mode.
+// The file is replaced in one step, so servers never read it half written.
+func WriteMaintenanceState(path string, state *MaintenanceState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), ".down-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// RemoveMaintenanceState removes the state file, bringing the application back up.
+func RemoveMaintenanceState(path string) error {
+	err := os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// maintenanceWatch caches the maintenance state, reading the state file again only when
+// it changes.
+type maintenanceWatch struct {
+	path  string
+	info  os.FileInfo // State file the state was read from
+	state *MaintenanceState
+	mu    sync.Mutex
+}
+
+// current returns the maintenance state, or nil if the application is up. A state file
+// that can't be read or parsed keeps the application down, without bypasses.
+func (m *maintenanceWatch) current() *MaintenanceState {
+	info, err := os.Stat(m.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return &MaintenanceState{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.info != nil && os.SameFile(m.info, info) && m.info.ModTime().Equal(info.ModTime()) && m.info.Size() == info.Size() {
+		return m.state
+	}
+	state, err := ReadMaintenanceState(m.path)
+	if err != nil {
+		state = &MaintenanceState{}
+	}
+	if state == nil {
+		return nil // Removed since it was checked
+	}
+	m.info, m.state = info, state
+	return state
+}
+
+// MaintenanceMiddleware returns 503 responses while the state file exists, rendering the
+// template at viewPath for browsers and JSON for API clients. Requests to the except
+// paths, from allowed IPs, or carrying the bypass cookie are let through. The state is
+// read again when the file changes; a file that can't be read means maintenance mode.
+func MaintenanceMiddleware(statePath, viewPath string, except ...string) func(http.Handler) http.Handler {
+	watch := &maintenanceWatch{path: statePath}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			state := watch.current()
+			if state == nil || isExcepted(r.URL.Path, except) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if state.Secret != "" {
+				token := bypassToken(state.Secret)
+				if r.URL.Path == "/"+state.Secret {
+					http.SetCookie(w, &http.Cookie{
+						Name:     maintenanceCookie,
+						Value:    token,
+						Path:     "/",
+						Expires:  time.Now().Add(12 * time.Hour),
+						HttpOnly: true,
+						SameSite: http.SameSiteLaxMode,
+					})
+					http.Redirect(w, r, "/", http.StatusFound)
+					return
+				}
+				if cookie, err := r.Cookie(maintenanceCookie); err == nil && cookie.Value == token {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			if isIPAllowed(r.RemoteAddr, state.Allow) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if state.Retry > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(state.Retry))
+			}
+			message := state.Message
+			if message == "" {
+				message = "Service Unavailable"
+			}
+
+			if wantsJSON(r) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"message":     message,
+					"retry_after": state.Retry,
+				})
+				return
+			}
+
+			tmpl, err := template.ParseFiles(viewPath)
+			if err != nil {
+				http.Error(w, message, http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			tmpl.Execute(w, state)
+		})
+	}
+}
+
+// MaintenanceMiddleware returns the maintenance middleware for this kernel, rendering the
+// under_construction view and leaving the health endpoints reachable.
+func (k *Kernel) MaintenanceMiddleware() func(http.Handler) http.Handler {
+	viewRoot := k.ConfigString("VIEW_ROOT", "./resources/views/")
+	viewPath := filepath.Join(viewRoot, "errors", "under_construction.html")
+	return MaintenanceMiddleware(MaintenanceFile, viewPath, k.healthLivePath, k.healthReadyPath)
+}
+
+// bypassToken derives the cookie value from the secret so the secret itself is not stored in cookies.
+func bypassToken(secret string) string {
+	sum := sha256.Sum256([]byte("icepeak-maintenance:" + secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// isExcepted reports whether a path is exempt from maintenance mode.
+func isExcepted(path string, except []string) bool {
+	for _, excepted := range except {
+		if excepted != "" && path == excepted {
+			return true
+		}
+	}
+	return false
+}
+
+// isIPAllowed reports whether the client address matches an allowed IP or CIDR range.
+func isIPAllowed(remoteAddr string, allowed []string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, entry := range allowed {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsJSON reports whether the client expects a JSON response.
+func wantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json") ||
+		strings.HasPrefix(r.URL.Path, "/api/") ||
+		r.Header.Get("X-Requested-With") == "XMLHttpRequest"
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// maintenanceStatus serves a request through the maintenance middleware and returns its status.
+func maintenanceStatus(handler http.Handler, remoteAddr string) int {
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	req.RemoteAddr = remoteAddr
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder.Code
+}
+
+func TestMaintenanceMiddlewareFollowsTheStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "down")
+	handler := MaintenanceMiddleware(path, "missing.html")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	if status := maintenanceStatus(handler, "10.0.0.1:1234"); status != http.StatusOK {
+		t.Fatalf("status is %d without a state file, want 200", status)
+	}
+
+	if err := WriteMaintenanceState(path, &MaintenanceState{Allow: []string{"10.0.0.1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if status := maintenanceStatus(handler, "10.0.0.2:1234"); status != http.StatusServiceUnavailable {
+		t.Fatalf("status is %d in maintenance, want 503", status)
+	}
+	if status := maintenanceStatus(handler, "10.0.0.1:1234"); status != http.StatusOK {
+		t.Fatalf("status is %d for an allowed IP, want 200", status)
+	}
+
+	// A new state replaces the cached one
+	if err := WriteMaintenanceState(path, &MaintenanceState{Allow: []string{"10.0.0.2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if status := maintenanceStatus(handler, "10.0.0.1:1234"); status != http.StatusServiceUnavailable {
+		t.Fatalf("status is %d for an IP no longer allowed, want 503", status)
+	}
+
+	if err := RemoveMaintenanceState(path); err != nil {
+		t.Fatal(err)
+	}
+	if status := maintenanceStatus(handler, "10.0.0.2:1234"); status != http.StatusOK {
+		t.Fatalf("status is %d after the state file was removed, want 200", status)
+	}
+}
+
+func TestMaintenanceMiddlewareTreatsUnreadableStateAsDown(t *testing.T) {
+	dir := t.TempDir()
+	corrupt, unreadable := filepath.Join(dir, "corrupt"), filepath.Join(dir, "unreadable")
+	if err := os.WriteFile(corrupt, []byte(`{"allow": ["10.0.0.1"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(unreadable, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{corrupt, unreadable} {
+		handler := MaintenanceMiddleware(path, "missing.html")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		if status := maintenanceStatus(handler, "10.0.0.1:1234"); status != http.StatusServiceUnavailable {
+			t.Errorf("status is %d with state file %s, want 503", status, filepath.Base(path))
+		}
+	}
+}
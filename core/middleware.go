@@ -1,10 +1,9 @@
 package core
 
 import (
-	"fmt"
 	"net/http"
-	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,8 +17,39 @@ type CORSOptions struct {
 
 // CORSMiddleware handles Cross-Origin Resource Sharing.
 func CORSMiddleware(options CORSOptions) func(http.Handler) http.Handler {
+	return NewCORSPolicy(options).Middleware()
+}
+
+// CORSPolicy holds CORS options that can be replaced while the server runs.
+type CORSPolicy struct {
+	options CORSOptions
+	mu      sync.RWMutex
+}
+
+// NewCORSPolicy creates a CORSPolicy with the given options.
+func NewCORSPolicy(options CORSOptions) *CORSPolicy {
+	return &CORSPolicy{options: options}
+}
+
+// Options returns the current options.
+func (p *CORSPolicy) Options() CORSOptions {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.options
+}
+
+// SetOptions replaces the options used by subsequent requests.
+func (p *CORSPolicy) SetOptions(options CORSOptions) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.options = options
+}
+
+// Middleware handles Cross-Origin Resource Sharing with the current options.
+func (p *CORSPolicy) Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			options := p.Options()
 			origin := r.Header.Get("Origin")
 			if origin != "" && isOriginAllowed(origin, options.AllowedOrigins) {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
@@ -42,6 +72,28 @@ func CORSMiddleware(options CORSOptions) func(http.Handler) http.Handler {
 	}
 }
 
+// CORSMiddleware handles Cross-Origin Resource Sharing with the CORS_* configuration
+// keys, picking up changes when the configuration is reloaded.
+func (k *Kernel) CORSMiddleware() func(http.Handler) http.Handler {
+	policy := NewCORSPolicy(k.corsOptions())
+	k.OnConfigChange(func(change ConfigChange) {
+		if change.Has("CORS_ALLOWED_ORIGINS", "CORS_ALLOWED_METHODS", "CORS_ALLOWED_HEADERS", "CORS_ALLOW_CREDENTIALS") {
+			policy.SetOptions(k.corsOptions())
+		}
+	})
+	return policy.Middleware()
+}
+
+// corsOptions reads the CORS options from the configuration.
+func (k *Kernel) corsOptions() CORSOptions {
+	return CORSOptions{
+		AllowedOrigins:   k.ConfigStrings("CORS_ALLOWED_ORIGINS", []string{"*"}),
+		AllowedMethods:   k.ConfigStrings("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+		AllowedHeaders:   k.ConfigStrings("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization"}),
+		AllowCredentials: k.ConfigString("CORS_ALLOW_CREDENTIALS", "false") == "true",
+	}
+}
+
 // Helper function to check if an origin is allowed.
 func isOriginAllowed(origin string, allowedOrigins []string) bool {
 	for _, allowed := range allowedOrigins {
@@ -75,45 +127,19 @@ func InputValidationMiddleware(requiredFields []string) func(http.Handler) http.
 	}
 }
 
-// RequestLoggingMiddleware logs details about the incoming request.
-func RequestLoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		// Resolve the logger service
-		logger, err := GetKernel().Services.Resolve("logger")
-		if err != nil {
-			fmt.Printf("Error resolving logger service: %v\n", err)
-			next.ServeHTTP(w, r)
-			return
-		}
+// RequestLoggingMiddleware logs details about the incoming request using the given logger.
+func RequestLoggingMiddleware(logger Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
 
-		// Cast the resolved service to *DefaultLogger and use it
-		defaultLogger, ok := logger.(*DefaultLogger)
-		if !ok {
-			fmt.Printf("Error casting logger service to *DefaultLogger\n")
+			// Proceed with the next handler
 			next.ServeHTTP(w, r)
-			return
-		}
-
-		// Proceed with the next handler
-		next.ServeHTTP(w, r)
 
-		// Log the request details after handling the request
-		clientIP := r.RemoteAddr
-		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-			clientIP = forwarded
-		}
-
-		requestSize := r.ContentLength
-		duration := time.Since(start)
-		var memStats runtime.MemStats
-		runtime.ReadMemStats(&memStats)
-
-		logMessage := fmt.Sprintf(
-			"Client IP: %s | Method: %s | Path: %s | Request Size: %d bytes | Duration: %v | Memory Usage: %d KB",
-			clientIP, r.Method, r.URL.Path, requestSize, duration, memStats.Alloc/1024,
-		)
-		defaultLogger.Info(logMessage)
-	})
+			// Log the request details after handling the request
+			if logger != nil {
+				logger.LogRequest(r, start)
+			}
+		})
+	}
 }
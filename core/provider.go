@@ -0,0 +1,51 @@
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// ServiceProvider registers services in the container and boots them once all providers are registered.
+type ServiceProvider interface {
+	// Register binds services into the container. It must not resolve other services.
+	Register(services *ServiceContainer)
+	// Boot runs after every provider has been registered.
+	Boot(kernel *Kernel) error
+}
+
+// RegisterProvider registers a service provider with the kernel.
+func (k *Kernel) RegisterProvider(provider ServiceProvider) {
+	provider.Register(k.Services)
+	k.providers = append(k.providers, provider)
+	if k.booted {
+		if err := provider.Boot(k); err != nil {
+			fmt.Printf("Error booting service provider %T: %v\n", provider, err)
+		}
+	}
+}
+
+// Boot boots the registered service providers, then compiles the routes, failing on routes
+// that use middleware that is not registered. It refuses to boot with the configuration
+// error reported by ConfigError. Calling it again has no effect.
+func (k *Kernel) Boot() error {
+	if k.booted {
+		return nil
+	}
+	if k.configErr != nil {
+		return k.configErr
+	}
+	k.booted = true
+	for _, provider := range k.providers {
+		if err := provider.Boot(k); err != nil {
+			return fmt.Errorf("booting service provider %T: %w", provider, err)
+		}
+	}
+	k.registerTaggedHealthChecks()
+
+	// Report routes using unknown middleware now rather than on their first request
+	if err := k.Router.Compile(); err != nil {
+		return err
+	}
+	k.Dispatch(context.Background(), KernelBooted{Kernel: k})
+	return nil
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"icepeak/core/queue"
+)
+
+// Queue returns the job queue of this kernel.
+func (k *Kernel) Queue() *queue.Queue {
+	return k.queue
+}
+
+// WithQueueDriver stores queued jobs with the given driver instead of the one chosen by
+// the QUEUE_DRIVER configuration key.
+func WithQueueDriver(driver queue.Driver) KernelOption {
+	return func(k *Kernel) {
+		k.queue.SetDriver(driver)
+	}
+}
+
+// configureQueue sets the configured driver unless WithQueueDriver set one.
+func (k *Kernel) configureQueue() {
+	if k.queue.Driver() == nil {
+		k.queue.SetDriver(k.queueDriver())
+	}
+}
+
+// queueDriver creates the driver chosen by the QUEUE_DRIVER configuration key: "file"
+// (the default) under QUEUE_PATH, "memory", or "sql" using the *sql.DB bound in the
+// container (by type or as the "db" service) and the QUEUE_SQL_DIALECT key.
+func (k *Kernel) queueDriver() queue.Driver {
+	switch k.ConfigString("QUEUE_DRIVER", "file") {
+	case "memory":
+		return queue.NewMemoryDriver()
+	case "sql":
+		return queue.Lazy(func() (queue.Driver, error) {
+			db, err := k.database()
+			if err != nil {
+				return nil, fmt.Errorf("the sql queue driver needs a database: %w", err)
+			}
+			driver := queue.NewSQLDriver(db, k.ConfigString("QUEUE_SQL_DIALECT", "sqlite"))
+			return driver, driver.Migrate(context.Background())
+		})
+	default:
+		return queue.NewFileDriver(k.ConfigString("QUEUE_PATH", "storage/queue"))
+	}
+}
+
+// NewQueueWorker creates a worker for the kernel's queue that logs through the kernel logger.
+func (k *Kernel) NewQueueWorker(queues ...string) *queue.Worker {
+	return &queue.Worker{Queue: k.queue, Queues: queues, Logger: kernelLogger{k}}
+}
+
+// kernelLogger sends the output of background work to the kernel logger.
+type kernelLogger struct {
+	kernel *Kernel
+}
+
+func (l kernelLogger) Info(msg string) {
+	if logger := l.kernel.Logger(); logger != nil {
+		logger.Info(msg)
+	}
+}
+
+func (l kernelLogger) Error(msg string) {
+	if logger := l.kernel.Logger(); logger != nil {
+		logger.Error(msg)
+	}
+}
@@ -0,0 +1,227 @@
+package queue
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultReservation is how long a popped job stays reserved before it is handed to
+// another worker, in case the worker that popped it died.
+const DefaultReservation = 5 * time.Minute
+
+// Driver stores jobs. Implementations must let several workers pop concurrently without
+// handing the same job to two of them.
+type Driver interface {
+	// Push stores a job until it is available.
+	Push(ctx context.Context, envelope *Envelope) error
+	// Pop reserves the next available job on the queue, returning nil if there is none. It
+	// counts the attempt in the stored job, so a job whose worker died is not retried forever.
+	Pop(ctx context.Context, queue string) (*Envelope, error)
+	// Delete removes a reserved job that completed.
+	Delete(ctx context.Context, envelope *Envelope) error
+	// Release returns a reserved job to its queue, available again at envelope.AvailableAt.
+	Release(ctx context.Context, envelope *Envelope) error
+	// Fail moves a reserved job to the failed jobs.
+	Fail(ctx context.Context, envelope *Envelope) error
+	// Failed returns the failed jobs, oldest first.
+	Failed(ctx context.Context) ([]*Envelope, error)
+	// Forget deletes a failed job.
+	Forget(ctx context.Context, id string) error
+}
+
+// MemoryDriver keeps jobs in memory. Jobs are lost when the process exits and only
+// workers in the same process see them, which suits tests and development.
+type MemoryDriver struct {
+	pending  map[string][]*Envelope
+	reserved map[string]reservation
+	failed   []*Envelope
+	mu       sync.Mutex
+}
+
+// reservation is a popped job and when its reservation expires.
+type reservation struct {
+	envelope *Envelope
+	until    time.Time
+}
+
+// NewMemoryDriver creates an empty in-memory driver.
+func NewMemoryDriver() *MemoryDriver {
+	return &MemoryDriver{pending: make(map[string][]*Envelope), reserved: make(map[string]reservation)}
+}
+
+// Push stores a job until it is available.
+func (d *MemoryDriver) Push(ctx context.Context, envelope *Envelope) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	copied := *envelope
+	d.pending[envelope.Queue] = append(d.pending[envelope.Queue], &copied)
+	return nil
+}
+
+// Pop reserves the available job on the queue that has waited longest.
+func (d *MemoryDriver) Pop(ctx context.Context, queue string) (*Envelope, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for id, r := range d.reserved {
+		if r.envelope.Queue == queue && now.After(r.until) {
+			delete(d.reserved, id)
+			d.pending[queue] = append(d.pending[queue], r.envelope)
+		}
+	}
+
+	jobs := d.pending[queue]
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].AvailableAt.Before(jobs[j].AvailableAt)
+	})
+	if len(jobs) == 0 || jobs[0].AvailableAt.After(now) {
+		return nil, nil
+	}
+
+	envelope := jobs[0]
+	envelope.Attempts++
+	d.pending[queue] = jobs[1:]
+	d.reserved[envelope.ID] = reservation{envelope: envelope, until: now.Add(DefaultReservation)}
+	copied := *envelope
+	return &copied, nil
+}
+
+// Delete removes a reserved job.
+func (d *MemoryDriver) Delete(ctx context.Context, envelope *Envelope) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.reserved, envelope.ID)
+	return nil
+}
+
+// Release returns a reserved job to its queue.
+func (d *MemoryDriver) Release(ctx context.Context, envelope *Envelope) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.reserved, envelope.ID)
+	copied := *envelope
+	d.pending[envelope.Queue] = append(d.pending[envelope.Queue], &copied)
+	return nil
+}
+
+// Fail moves a reserved job to the failed jobs.
+func (d *MemoryDriver) Fail(ctx context.Context, envelope *Envelope) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.reserved, envelope.ID)
+	copied := *envelope
+	d.failed = append(d.failed, &copied)
+	return nil
+}
+
+// Failed returns the failed jobs, oldest first.
+func (d *MemoryDriver) Failed(ctx context.Context) ([]*Envelope, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	failed := []*Envelope{}
+	for _, envelope := range d.failed {
+		copied := *envelope
+		failed = append(failed, &copied)
+	}
+	return failed, nil
+}
+
+// Forget deletes a failed job.
+func (d *MemoryDriver) Forget(ctx context.Context, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, envelope := range d.failed {
+		if envelope.ID == id {
+			d.failed = append(d.failed[:i:i], d.failed[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// lazyDriver resolves its driver on first use.
+type lazyDriver struct {
+	resolve func() (Driver, error)
+	driver  Driver
+	mu      sync.Mutex
+}
+
+// Lazy returns a driver that calls resolve on first use, for drivers that depend on
+// services not available yet when the queue is created, such as a database connection.
+// A failed resolution is tried again on the next use.
+func Lazy(resolve func() (Driver, error)) Driver {
+	return &lazyDriver{resolve: resolve}
+}
+
+// get resolves the driver until it succeeds.
+func (d *lazyDriver) get() (Driver, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.driver == nil {
+		driver, err := d.resolve()
+		if err != nil {
+			return nil, err
+		}
+		d.driver = driver
+	}
+	return d.driver, nil
+}
+
+func (d *lazyDriver) Push(ctx context.Context, envelope *Envelope) error {
+	driver, err := d.get()
+	if err != nil {
+		return err
+	}
+	return driver.Push(ctx, envelope)
+}
+
+func (d *lazyDriver) Pop(ctx context.Context, queue string) (*Envelope, error) {
+	driver, err := d.get()
+	if err != nil {
+		return nil, err
+	}
+	return driver.Pop(ctx, queue)
+}
+
+func (d *lazyDriver) Delete(ctx context.Context, envelope *Envelope) error {
+	driver, err := d.get()
+	if err != nil {
+		return err
+	}
+	return driver.Delete(ctx, envelope)
+}
+
+func (d *lazyDriver) Release(ctx context.Context, envelope *Envelope) error {
+	driver, err := d.get()
+	if err != nil {
+		return err
+	}
+	return driver.Release(ctx, envelope)
+}
+
+func (d *lazyDriver) Fail(ctx context.Context, envelope *Envelope) error {
+	driver, err := d.get()
+	if err != nil {
+		return err
+	}
+	return driver.Fail(ctx, envelope)
+}
+
+func (d *lazyDriver) Failed(ctx context.Context) ([]*Envelope, error) {
+	driver, err := d.get()
+	if err != nil {
+		return nil, err
+	}
+	return driver.Failed(ctx)
+}
+
+func (d *lazyDriver) Forget(ctx context.Context, id string) error {
+	driver, err := d.get()
+	if err != nil {
+		return err
+	}
+	return driver.Forget(ctx, id)
+}
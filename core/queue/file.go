@@ -0,0 +1,249 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileDriver keeps jobs as JSON files in a directory, e.g. "storage/queue", so workers on
+// the same host share them. Files move between directories with atomic renames:
+//
+//	<dir>/<queue>/pending/<available at>-<id>.json
+//	<dir>/<queue>/reserved/<id>.json
+//	<dir>/failed/<failed at>-<id>.json
+type FileDriver struct {
+	dir string
+}
+
+// NewFileDriver creates a driver storing jobs under dir.
+func NewFileDriver(dir string) *FileDriver {
+	return &FileDriver{dir: dir}
+}
+
+// Push stores a job until it is available.
+func (d *FileDriver) Push(ctx context.Context, envelope *Envelope) error {
+	if err := validQueueName(envelope.Queue); err != nil {
+		return err
+	}
+	return d.write(d.pendingPath(envelope), envelope)
+}
+
+// Pop reserves the available job on the queue that has waited longest.
+func (d *FileDriver) Pop(ctx context.Context, queue string) (*Envelope, error) {
+	if err := validQueueName(queue); err != nil {
+		return nil, err
+	}
+	if err := d.recoverExpired(queue); err != nil {
+		return nil, err
+	}
+
+	pending := filepath.Join(d.dir, queue, "pending")
+	names, err := readNames(pending)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, name := range names {
+		// Names start with the time the job becomes available, so they sort by it
+		if name > fmt.Sprintf("%020d", now.UnixNano()) {
+			break
+		}
+		id := strings.TrimSuffix(name[strings.Index(name, "-")+1:], ".json")
+		reserved := filepath.Join(d.dir, queue, "reserved", id+".json")
+		if err := os.MkdirAll(filepath.Dir(reserved), 0755); err != nil {
+			return nil, err
+		}
+
+		// Only one worker wins the rename; the others move on to the next job
+		if err := os.Rename(filepath.Join(pending, name), reserved); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		os.Chtimes(reserved, now, now)
+
+		// Count the attempt in the reserved file, which also keeps its time as the reservation time
+		envelope, err := readEnvelope(reserved)
+		if err != nil {
+			return nil, err
+		}
+		envelope.Attempts++
+		if err := d.write(reserved, envelope); err != nil {
+			return nil, err
+		}
+		return envelope, nil
+	}
+	return nil, nil
+}
+
+// Delete removes a reserved job.
+func (d *FileDriver) Delete(ctx context.Context, envelope *Envelope) error {
+	return removeIfExists(d.reservedPath(envelope))
+}
+
+// Release returns a reserved job to its queue.
+func (d *FileDriver) Release(ctx context.Context, envelope *Envelope) error {
+	if err := d.write(d.pendingPath(envelope), envelope); err != nil {
+		return err
+	}
+	return removeIfExists(d.reservedPath(envelope))
+}
+
+// Fail moves a reserved job to the failed jobs.
+func (d *FileDriver) Fail(ctx context.Context, envelope *Envelope) error {
+	path := filepath.Join(d.dir, "failed", fmt.Sprintf("%020d-%s.json", envelope.FailedAt.UnixNano(), envelope.ID))
+	if err := d.write(path, envelope); err != nil {
+		return err
+	}
+	return removeIfExists(d.reservedPath(envelope))
+}
+
+// Failed returns the failed jobs, oldest first.
+func (d *FileDriver) Failed(ctx context.Context) ([]*Envelope, error) {
+	dir := filepath.Join(d.dir, "failed")
+	names, err := readNames(dir)
+	if err != nil {
+		return nil, err
+	}
+	failed := []*Envelope{}
+	for _, name := range names {
+		envelope, err := readEnvelope(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		failed = append(failed, envelope)
+	}
+	return failed, nil
+}
+
+// Forget deletes a failed job.
+func (d *FileDriver) Forget(ctx context.Context, id string) error {
+	dir := filepath.Join(d.dir, "failed")
+	names, err := readNames(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if strings.HasSuffix(name, "-"+id+".json") {
+			return os.Remove(filepath.Join(dir, name))
+		}
+	}
+	return ErrNotFound
+}
+
+// recoverExpired returns jobs whose reservation expired to the pending jobs.
+func (d *FileDriver) recoverExpired(queue string) error {
+	dir := filepath.Join(d.dir, queue, "reserved")
+	names, err := readNames(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil || time.Since(info.ModTime()) < DefaultReservation {
+			continue
+		}
+		envelope, err := readEnvelope(path)
+		if err != nil {
+			continue
+		}
+		if err := os.Rename(path, d.pendingPath(envelope)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingPath returns the file of a job waiting on its queue.
+func (d *FileDriver) pendingPath(envelope *Envelope) string {
+	return filepath.Join(d.dir, envelope.Queue, "pending", fmt.Sprintf("%020d-%s.json", envelope.AvailableAt.UnixNano(), envelope.ID))
+}
+
+// reservedPath returns the file of a reserved job.
+func (d *FileDriver) reservedPath(envelope *Envelope) string {
+	return filepath.Join(d.dir, envelope.Queue, "reserved", envelope.ID+".json")
+}
+
+// write writes an envelope to a temporary file and renames it into place, so readers
+// never see a partial file.
+func (d *FileDriver) write(path string, envelope *Envelope) error {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(d.dir, ".job-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// readEnvelope reads a job file.
+func readEnvelope(path string) (*Envelope, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	envelope := &Envelope{}
+	if err := json.Unmarshal(data, envelope); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return envelope, nil
+}
+
+// readNames returns the sorted names of the job files in a directory, which may not exist.
+func readNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// removeIfExists removes a file, ignoring that it is already gone.
+func removeIfExists(path string) error {
+	err := os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// validQueueName rejects queue names that would escape the queue directory.
+func validQueueName(name string) error {
+	if name == "" || name == "failed" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid queue name '%s'", name)
+	}
+	return nil
+}
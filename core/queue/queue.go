@@ -0,0 +1,294 @@
+// Package queue runs jobs in the background with retries, delays and failed-job storage.
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// DefaultQueue is the queue jobs are pushed to unless OnQueue says otherwise.
+const DefaultQueue = "default"
+
+// DefaultTries is how many times a job is attempted unless Tries says otherwise.
+const DefaultTries = 3
+
+// Job is a unit of background work. Jobs are stored as JSON, so their exported fields
+// carry the data they need, and their type must be registered with Queue.Register.
+type Job interface {
+	Handle(ctx context.Context) error
+}
+
+// Named is implemented by jobs that choose the name they are stored under instead of
+// their Go type name, which keeps stored jobs valid when the type is renamed or moved.
+type Named interface {
+	JobName() string
+}
+
+// HandlerFunc processes a job.
+type HandlerFunc func(ctx context.Context, job Job) error
+
+// Middleware wraps the processing of a job, e.g. to log, rate limit or skip it.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// WithMiddleware is implemented by jobs that need middleware of their own. It runs
+// inside the middleware of the queue.
+type WithMiddleware interface {
+	Middleware() []Middleware
+}
+
+// Envelope is a job as stored by a driver, with its delivery state.
+type Envelope struct {
+	ID          string          `json:"id"`
+	Queue       string          `json:"queue"`
+	Job         string          `json:"job"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	MaxTries    int             `json:"max_tries"`
+	Backoff     []time.Duration `json:"backoff,omitempty"`
+	AvailableAt time.Time       `json:"available_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Error       string          `json:"error,omitempty"`     // Error of the last attempt
+	FailedAt    time.Time       `json:"failed_at,omitempty"` // Set once the job is moved to the failed jobs
+}
+
+// DispatchOption configures a dispatched job.
+type DispatchOption func(*Envelope)
+
+// OnQueue pushes the job to the named queue.
+func OnQueue(name string) DispatchOption {
+	return func(e *Envelope) {
+		e.Queue = name
+	}
+}
+
+// Delay makes the job available only after the given duration.
+func Delay(delay time.Duration) DispatchOption {
+	return func(e *Envelope) {
+		e.AvailableAt = e.AvailableAt.Add(delay)
+	}
+}
+
+// Tries sets how many times the job is attempted before it fails.
+func Tries(tries int) DispatchOption {
+	return func(e *Envelope) {
+		e.MaxTries = tries
+	}
+}
+
+// Backoff sets the delays before each retry; the last one repeats for later retries.
+// Without it, retries wait 2^attempts seconds, up to an hour.
+func Backoff(delays ...time.Duration) DispatchOption {
+	return func(e *Envelope) {
+		e.Backoff = delays
+	}
+}
+
+// Queue dispatches jobs to a driver and decodes them for workers.
+type Queue struct {
+	driver     Driver
+	types      map[string]reflect.Type
+	middleware []Middleware
+	mu         sync.RWMutex
+}
+
+// New creates a queue storing jobs with the given driver.
+func New(driver Driver) *Queue {
+	return &Queue{driver: driver, types: make(map[string]reflect.Type)}
+}
+
+// Driver returns the driver storing the jobs.
+func (q *Queue) Driver() Driver {
+	return q.currentDriver()
+}
+
+// SetDriver replaces the driver storing the jobs.
+func (q *Queue) SetDriver(driver Driver) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.driver = driver
+}
+
+// Register registers job types so workers can decode them. Pass a zero value of each
+// type, e.g. queue.Register(SendWelcomeMail{}, &ResizeImage{}).
+func (q *Queue) Register(jobs ...Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range jobs {
+		q.types[JobName(job)] = reflect.TypeOf(job)
+	}
+}
+
+// Use adds middleware run around every job, the first one outermost.
+func (q *Queue) Use(middleware ...Middleware) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.middleware = append(q.middleware, middleware...)
+}
+
+// Dispatch pushes a job to the queue and returns its ID.
+func (q *Queue) Dispatch(ctx context.Context, job Job, options ...DispatchOption) (string, error) {
+	name := JobName(job)
+	q.mu.RLock()
+	_, registered := q.types[name]
+	driver := q.driver
+	q.mu.RUnlock()
+	if !registered {
+		return "", fmt.Errorf("job %s is not registered", name)
+	}
+
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return "", fmt.Errorf("encoding job %s: %w", name, err)
+	}
+	now := time.Now()
+	envelope := &Envelope{
+		ID:          newID(),
+		Queue:       DefaultQueue,
+		Job:         name,
+		Payload:     payload,
+		MaxTries:    DefaultTries,
+		AvailableAt: now,
+		CreatedAt:   now,
+	}
+	for _, option := range options {
+		option(envelope)
+	}
+	if envelope.MaxTries < 1 {
+		envelope.MaxTries = 1
+	}
+	return envelope.ID, driver.Push(ctx, envelope)
+}
+
+// Decode restores the job stored in an envelope.
+func (q *Queue) Decode(envelope *Envelope) (Job, error) {
+	q.mu.RLock()
+	typ, ok := q.types[envelope.Job]
+	q.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("job %s is not registered", envelope.Job)
+	}
+
+	var value reflect.Value
+	if typ.Kind() == reflect.Ptr {
+		value = reflect.New(typ.Elem())
+	} else {
+		value = reflect.New(typ)
+	}
+	if err := json.Unmarshal(envelope.Payload, value.Interface()); err != nil {
+		return nil, fmt.Errorf("decoding job %s: %w", envelope.Job, err)
+	}
+	if typ.Kind() != reflect.Ptr {
+		value = value.Elem()
+	}
+	return value.Interface().(Job), nil
+}
+
+// Process runs a job through the queue and job middleware. Panics become errors.
+func (q *Queue) Process(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	q.mu.RLock()
+	middleware := append([]Middleware{}, q.middleware...)
+	q.mu.RUnlock()
+	if m, ok := job.(WithMiddleware); ok {
+		middleware = append(middleware, m.Middleware()...)
+	}
+
+	var handler HandlerFunc = func(ctx context.Context, job Job) error {
+		return job.Handle(ctx)
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler(ctx, job)
+}
+
+// Failed returns the failed jobs, oldest first.
+func (q *Queue) Failed(ctx context.Context) ([]*Envelope, error) {
+	return q.currentDriver().Failed(ctx)
+}
+
+// Retry pushes a failed job back onto its queue with its attempts reset.
+func (q *Queue) Retry(ctx context.Context, id string) error {
+	driver := q.currentDriver()
+	failed, err := driver.Failed(ctx)
+	if err != nil {
+		return err
+	}
+	for _, envelope := range failed {
+		if envelope.ID != id {
+			continue
+		}
+		envelope.Attempts = 0
+		envelope.Error = ""
+		envelope.FailedAt = time.Time{}
+		envelope.AvailableAt = time.Now()
+		if err := driver.Push(ctx, envelope); err != nil {
+			return err
+		}
+		return driver.Forget(ctx, id)
+	}
+	return fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// Forget deletes a failed job.
+func (q *Queue) Forget(ctx context.Context, id string) error {
+	return q.currentDriver().Forget(ctx, id)
+}
+
+// currentDriver returns the driver under the read lock.
+func (q *Queue) currentDriver() Driver {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.driver
+}
+
+// ErrNotFound is returned for unknown failed job IDs.
+var ErrNotFound = errors.New("failed job not found")
+
+// JobName returns the name a job is stored under.
+func JobName(job Job) string {
+	if named, ok := job.(Named); ok {
+		return named.JobName()
+	}
+	typ := reflect.TypeOf(job)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.String()
+}
+
+// backoff returns how long to wait before retrying a job that has been attempted.
+func backoff(envelope *Envelope) time.Duration {
+	if n := len(envelope.Backoff); n > 0 {
+		if envelope.Attempts < 1 {
+			return envelope.Backoff[0]
+		}
+		if envelope.Attempts <= n {
+			return envelope.Backoff[envelope.Attempts-1]
+		}
+		return envelope.Backoff[n-1]
+	}
+	if envelope.Attempts >= 12 {
+		return time.Hour
+	}
+	return time.Duration(1<<envelope.Attempts) * time.Second
+}
+
+// newID returns a random job ID.
+func newID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
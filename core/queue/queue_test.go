@@ -0,0 +1,267 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDriver checks the behavior every driver shares.
+func testDriver(t *testing.T, driver Driver) {
+	ctx := context.Background()
+	now := time.Now()
+	push := func(id string, availableAt time.Time) *Envelope {
+		envelope := &Envelope{ID: id, Queue: DefaultQueue, Job: "test", MaxTries: 3, AvailableAt: availableAt, CreatedAt: now}
+		if err := driver.Push(ctx, envelope); err != nil {
+			t.Fatalf("pushing %s: %v", id, err)
+		}
+		return envelope
+	}
+	pop := func(want string) *Envelope {
+		t.Helper()
+		envelope, err := driver.Pop(ctx, DefaultQueue)
+		if err != nil {
+			t.Fatalf("popping: %v", err)
+		}
+		got := ""
+		if envelope != nil {
+			got = envelope.ID
+		}
+		if got != want {
+			t.Fatalf("popped %q, want %q", got, want)
+		}
+		return envelope
+	}
+
+	push("later", now.Add(time.Hour))
+	push("second", now.Add(-time.Second))
+	push("first", now.Add(-time.Minute))
+
+	first := pop("first")
+	second := pop("second")
+	pop("") // The reserved jobs are not handed out again and "later" is not available yet
+	if first.Attempts != 1 {
+		t.Fatalf("popped job has %d attempts, want the attempt counted", first.Attempts)
+	}
+
+	first.Error = "boom"
+	first.AvailableAt = now.Add(-time.Second)
+	if err := driver.Release(ctx, first); err != nil {
+		t.Fatalf("releasing: %v", err)
+	}
+	released := pop("first")
+	if released.Attempts != 2 || released.Error != "boom" {
+		t.Fatalf("released job has %d attempts and error %q, want the state it was released with and another attempt", released.Attempts, released.Error)
+	}
+
+	if err := driver.Delete(ctx, second); err != nil {
+		t.Fatalf("deleting: %v", err)
+	}
+	released.FailedAt = now
+	if err := driver.Fail(ctx, released); err != nil {
+		t.Fatalf("failing: %v", err)
+	}
+	pop("")
+
+	failed, err := driver.Failed(ctx)
+	if err != nil {
+		t.Fatalf("listing failed jobs: %v", err)
+	}
+	if len(failed) != 1 || failed[0].ID != "first" || failed[0].Error != "boom" {
+		t.Fatalf("failed jobs are %+v, want only first", failed)
+	}
+	if err := driver.Forget(ctx, "first"); err != nil {
+		t.Fatalf("forgetting: %v", err)
+	}
+	if err := driver.Forget(ctx, "first"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("forgetting twice returned %v, want ErrNotFound", err)
+	}
+	if failed, _ := driver.Failed(ctx); len(failed) != 0 {
+		t.Fatalf("failed jobs are %+v after forgetting, want none", failed)
+	}
+}
+
+func TestMemoryDriver(t *testing.T) {
+	testDriver(t, NewMemoryDriver())
+}
+
+func TestFileDriver(t *testing.T) {
+	testDriver(t, NewFileDriver(t.TempDir()))
+}
+
+func TestFileDriverHandsOutJobsWhoseReservationExpired(t *testing.T) {
+	dir := t.TempDir()
+	driver := NewFileDriver(dir)
+	ctx := context.Background()
+	if err := driver.Push(ctx, &Envelope{ID: "job", Queue: DefaultQueue, AvailableAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if envelope, err := driver.Pop(ctx, DefaultQueue); err != nil || envelope == nil {
+		t.Fatalf("popped %v, %v, want the job", envelope, err)
+	}
+
+	expired := time.Now().Add(-DefaultReservation - time.Second)
+	if err := os.Chtimes(filepath.Join(dir, DefaultQueue, "reserved", "job.json"), expired, expired); err != nil {
+		t.Fatal(err)
+	}
+	if envelope, err := driver.Pop(ctx, DefaultQueue); err != nil || envelope == nil || envelope.ID != "job" {
+		t.Fatalf("popped %v, %v, want the job again", envelope, err)
+	}
+}
+
+func TestWorkerFailsJobsWhoseAttemptsNeverFinished(t *testing.T) {
+	dir := t.TempDir()
+	driver := NewFileDriver(dir)
+	ctx := context.Background()
+	queue := New(driver)
+	queue.Register(flakyJob{})
+	if _, err := queue.Dispatch(ctx, flakyJob{Name: "crashing"}, Tries(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The workers running the first two attempts die before finishing them
+	for attempt := 1; attempt <= 2; attempt++ {
+		envelope, err := driver.Pop(ctx, DefaultQueue)
+		if err != nil || envelope == nil || envelope.Attempts != attempt {
+			t.Fatalf("popped %+v, %v, want attempt %d", envelope, err, attempt)
+		}
+		expired := time.Now().Add(-DefaultReservation - time.Second)
+		if err := os.Chtimes(filepath.Join(dir, DefaultQueue, "reserved", envelope.ID+".json"), expired, expired); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	(&Worker{Queue: queue, StopWhenEmpty: true}).Run(ctx)
+	failed, _ := driver.Failed(ctx)
+	if attempts.counts["crashing"] != 0 || len(failed) != 1 || failed[0].Attempts != 2 {
+		t.Fatalf("job ran %d more times with failed jobs %+v, want it failed after its 2 attempts", attempts.counts["crashing"], failed)
+	}
+}
+
+func TestFileDriverRejectsQueueNamesOutsideItsDirectory(t *testing.T) {
+	driver := NewFileDriver(t.TempDir())
+	for _, name := range []string{"", "failed", "../jobs", `a\b`, ".hidden"} {
+		if err := driver.Push(context.Background(), &Envelope{ID: "job", Queue: name}); err == nil {
+			t.Errorf("pushing to queue %q succeeded, want an error", name)
+		}
+	}
+}
+
+func TestLazyDriverResolvesAgainAfterAnError(t *testing.T) {
+	calls := 0
+	driver := Lazy(func() (Driver, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("database unavailable")
+		}
+		return NewMemoryDriver(), nil
+	})
+
+	envelope := &Envelope{ID: "job", Queue: DefaultQueue, AvailableAt: time.Now()}
+	if err := driver.Push(context.Background(), envelope); err == nil {
+		t.Fatal("pushing succeeded while the driver is unavailable")
+	}
+	if err := driver.Push(context.Background(), envelope); err != nil {
+		t.Fatalf("pushing after the driver recovered: %v", err)
+	}
+	if popped, _ := driver.Pop(context.Background(), DefaultQueue); popped == nil || calls != 2 {
+		t.Fatalf("popped %v after %d resolutions, want the job after 2", popped, calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		backoff  []time.Duration
+		want     time.Duration
+	}{
+		{attempts: 0, want: time.Second},
+		{attempts: 1, want: 2 * time.Second},
+		{attempts: 3, want: 8 * time.Second},
+		{attempts: 11, want: 2048 * time.Second},
+		{attempts: 12, want: time.Hour},
+		{attempts: 100, want: time.Hour},
+		{attempts: 0, backoff: []time.Duration{time.Second, time.Minute}, want: time.Second},
+		{attempts: 1, backoff: []time.Duration{time.Second, time.Minute}, want: time.Second},
+		{attempts: 2, backoff: []time.Duration{time.Second, time.Minute}, want: time.Minute},
+		{attempts: 5, backoff: []time.Duration{time.Second, time.Minute}, want: time.Minute},
+	}
+	for _, test := range tests {
+		got := backoff(&Envelope{Attempts: test.attempts, Backoff: test.backoff})
+		if got != test.want {
+			t.Errorf("backoff after %d attempts with %v = %v, want %v", test.attempts, test.backoff, got, test.want)
+		}
+	}
+}
+
+// attempts counts the attempts of flakyJob by name.
+var attempts = struct {
+	counts    map[string]int
+	deadlines map[string]time.Time
+	mu        sync.Mutex
+}{counts: map[string]int{}, deadlines: map[string]time.Time{}}
+
+// flakyJob fails its first Failures attempts.
+type flakyJob struct {
+	Name     string
+	Failures int
+}
+
+func (j flakyJob) Handle(ctx context.Context) error {
+	attempts.mu.Lock()
+	defer attempts.mu.Unlock()
+	attempts.counts[j.Name]++
+	attempts.deadlines[j.Name], _ = ctx.Deadline()
+	if attempts.counts[j.Name] <= j.Failures {
+		return fmt.Errorf("attempt %d failed", attempts.counts[j.Name])
+	}
+	return nil
+}
+
+// work dispatches a job and works the queue until it is empty.
+func work(t *testing.T, worker *Worker, job Job, options ...DispatchOption) *MemoryDriver {
+	driver := NewMemoryDriver()
+	worker.Queue = New(driver)
+	worker.Queue.Register(flakyJob{})
+	worker.StopWhenEmpty = true
+	if _, err := worker.Queue.Dispatch(context.Background(), job, options...); err != nil {
+		t.Fatal(err)
+	}
+	worker.Run(context.Background())
+	return driver
+}
+
+func TestWorkerRetriesFailedAttempts(t *testing.T) {
+	driver := work(t, &Worker{}, flakyJob{Name: "retried", Failures: 2}, Backoff(0))
+
+	failed, _ := driver.Failed(context.Background())
+	if attempts.counts["retried"] != 3 || len(failed) != 0 {
+		t.Fatalf("job attempted %d times with %d failed jobs, want 3 attempts and none failed", attempts.counts["retried"], len(failed))
+	}
+}
+
+func TestWorkerFailsJobsOutOfTries(t *testing.T) {
+	driver := work(t, &Worker{}, flakyJob{Name: "failed", Failures: 5}, Tries(2), Backoff(0))
+
+	failed, _ := driver.Failed(context.Background())
+	if attempts.counts["failed"] != 2 || len(failed) != 1 {
+		t.Fatalf("job attempted %d times with %d failed jobs, want 2 attempts and the job failed", attempts.counts["failed"], len(failed))
+	}
+	if failed[0].Attempts != 2 || failed[0].Error != "attempt 2 failed" || failed[0].FailedAt.IsZero() {
+		t.Fatalf("failed job is %+v, want its attempts, last error and failure time", failed[0])
+	}
+}
+
+func TestWorkerLimitsTimeoutsToTheReservation(t *testing.T) {
+	start := time.Now()
+	work(t, &Worker{Timeout: time.Hour}, flakyJob{Name: "slow"})
+
+	if deadline := attempts.deadlines["slow"]; deadline.After(start.Add(DefaultReservation)) {
+		t.Fatalf("job deadline is %v after it started, want it before the reservation expires", deadline.Sub(start))
+	}
+}
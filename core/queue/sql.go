@@ -0,0 +1,205 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SQLDriver keeps jobs in a database through database/sql, so workers on every host share
+// them. Run Migrate once to create its tables.
+type SQLDriver struct {
+	db          *sql.DB
+	dialect     string
+	jobsTable   string
+	failedTable string
+}
+
+// NewSQLDriver creates a driver using the jobs and failed_jobs tables. The dialect is
+// "postgres", "mysql" or "sqlite"; only postgres uses $1-style placeholders.
+func NewSQLDriver(db *sql.DB, dialect string) *SQLDriver {
+	return &SQLDriver{db: db, dialect: dialect, jobsTable: "jobs", failedTable: "failed_jobs"}
+}
+
+// Migrate creates the tables of the driver if they don't exist.
+func (d *SQLDriver) Migrate(ctx context.Context) error {
+	// MySQL has no CREATE INDEX IF NOT EXISTS, so it gets the index inline
+	index, statements := "", []string{}
+	if d.dialect == "mysql" {
+		index = ",\n\t\t\tINDEX queue_available_at (queue, available_at)"
+	} else {
+		statements = append(statements, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_queue_available_at ON %s (queue, available_at)", d.jobsTable, d.jobsTable))
+	}
+	statements = append([]string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id VARCHAR(64) PRIMARY KEY,
+			queue VARCHAR(255) NOT NULL,
+			payload TEXT NOT NULL,
+			available_at BIGINT NOT NULL,
+			reserved_until BIGINT NOT NULL DEFAULT 0%s
+		)`, d.jobsTable, index),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id VARCHAR(64) PRIMARY KEY,
+			queue VARCHAR(255) NOT NULL,
+			payload TEXT NOT NULL,
+			failed_at BIGINT NOT NULL
+		)`, d.failedTable),
+	}, statements...)
+
+	for _, statement := range statements {
+		if _, err := d.db.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Push stores a job until it is available.
+func (d *SQLDriver) Push(ctx context.Context, envelope *Envelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.ExecContext(ctx, d.query("INSERT INTO %s (id, queue, payload, available_at, reserved_until) VALUES (?, ?, ?, ?, 0)", d.jobsTable),
+		envelope.ID, envelope.Queue, string(payload), envelope.AvailableAt.UnixMilli())
+	return err
+}
+
+// Pop reserves the available job on the queue that has waited longest. Jobs whose
+// reservation expired are available again.
+func (d *SQLDriver) Pop(ctx context.Context, queue string) (*Envelope, error) {
+	for attempt := 0; attempt < 5; attempt++ {
+		now := time.Now()
+		var id, payload string
+		err := d.db.QueryRowContext(ctx,
+			d.query("SELECT id, payload FROM %s WHERE queue = ? AND available_at <= ? AND reserved_until <= ? ORDER BY available_at LIMIT 1", d.jobsTable),
+			queue, now.UnixMilli(), now.UnixMilli()).Scan(&id, &payload)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		envelope := &Envelope{}
+		if err := json.Unmarshal([]byte(payload), envelope); err != nil {
+			return nil, fmt.Errorf("reading job %s: %w", id, err)
+		}
+		envelope.Attempts++
+		reserved, err := json.Marshal(envelope)
+		if err != nil {
+			return nil, err
+		}
+
+		// Reserve the job and count the attempt, unless another worker reserved it first
+		result, err := d.db.ExecContext(ctx,
+			d.query("UPDATE %s SET reserved_until = ?, payload = ? WHERE id = ? AND reserved_until <= ?", d.jobsTable),
+			now.Add(DefaultReservation).UnixMilli(), string(reserved), id, now.UnixMilli())
+		if err != nil {
+			return nil, err
+		}
+		if n, err := result.RowsAffected(); err != nil || n != 1 {
+			continue
+		}
+		return envelope, nil
+	}
+	return nil, nil
+}
+
+// Delete removes a reserved job.
+func (d *SQLDriver) Delete(ctx context.Context, envelope *Envelope) error {
+	_, err := d.db.ExecContext(ctx, d.query("DELETE FROM %s WHERE id = ?", d.jobsTable), envelope.ID)
+	return err
+}
+
+// Release returns a reserved job to its queue.
+func (d *SQLDriver) Release(ctx context.Context, envelope *Envelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.ExecContext(ctx, d.query("UPDATE %s SET payload = ?, available_at = ?, reserved_until = 0 WHERE id = ?", d.jobsTable),
+		string(payload), envelope.AvailableAt.UnixMilli(), envelope.ID)
+	return err
+}
+
+// Fail moves a reserved job to the failed jobs.
+func (d *SQLDriver) Fail(ctx context.Context, envelope *Envelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, d.query("INSERT INTO %s (id, queue, payload, failed_at) VALUES (?, ?, ?, ?)", d.failedTable),
+		envelope.ID, envelope.Queue, string(payload), envelope.FailedAt.UnixMilli()); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, d.query("DELETE FROM %s WHERE id = ?", d.jobsTable), envelope.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Failed returns the failed jobs, oldest first.
+func (d *SQLDriver) Failed(ctx context.Context) ([]*Envelope, error) {
+	rows, err := d.db.QueryContext(ctx, d.query("SELECT payload FROM %s ORDER BY failed_at", d.failedTable))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	failed := []*Envelope{}
+	for rows.Next() {
+		var payload string
+		if err := rows.Scan(&payload); err != nil {
+			return nil, err
+		}
+		envelope := &Envelope{}
+		if err := json.Unmarshal([]byte(payload), envelope); err != nil {
+			return nil, err
+		}
+		failed = append(failed, envelope)
+	}
+	return failed, rows.Err()
+}
+
+// Forget deletes a failed job.
+func (d *SQLDriver) Forget(ctx context.Context, id string) error {
+	result, err := d.db.ExecContext(ctx, d.query("DELETE FROM %s WHERE id = ?", d.failedTable), id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// query inserts the table name and rewrites placeholders for the dialect.
+func (d *SQLDriver) query(format, table string) string {
+	query := fmt.Sprintf(format, table)
+	if d.dialect != "postgres" {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
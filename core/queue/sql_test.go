@@ -0,0 +1,261 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDatabase understands the statements of SQLDriver, so the driver can be tested
+// without a database server.
+type fakeDatabase struct {
+	jobs   map[string]fakeJob
+	failed map[string]fakeJob
+	// beforeReserve runs between the SELECT and the UPDATE of Pop, like another worker would
+	beforeReserve func(db *fakeDatabase, id string)
+	statements    []string
+	mu            sync.Mutex
+}
+
+// fakeJob is a row of the jobs or failed_jobs table.
+type fakeJob struct {
+	queue, payload string
+	at, reserved   int64
+}
+
+func newFakeDatabase() *fakeDatabase {
+	return &fakeDatabase{jobs: map[string]fakeJob{}, failed: map[string]fakeJob{}}
+}
+
+func (db *fakeDatabase) Open(name string) (driver.Conn, error) {
+	return db, nil
+}
+
+func (db *fakeDatabase) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (db *fakeDatabase) Close() error {
+	return nil
+}
+
+func (db *fakeDatabase) Begin() (driver.Tx, error) {
+	return db, nil
+}
+
+func (db *fakeDatabase) Commit() error {
+	return nil
+}
+
+func (db *fakeDatabase) Rollback() error {
+	return nil
+}
+
+func (db *fakeDatabase) ExecContext(ctx context.Context, query string, named []driver.NamedValue) (driver.Result, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, query)
+	args := values(named)
+
+	switch {
+	case strings.HasPrefix(query, "CREATE"):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(query, "INSERT INTO jobs"):
+		db.jobs[args[0].(string)] = fakeJob{queue: args[1].(string), payload: args[2].(string), at: args[3].(int64)}
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(query, "INSERT INTO failed_jobs"):
+		db.failed[args[0].(string)] = fakeJob{queue: args[1].(string), payload: args[2].(string), at: args[3].(int64)}
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(query, "UPDATE jobs SET reserved_until"):
+		id := args[2].(string)
+		if db.beforeReserve != nil {
+			db.beforeReserve(db, id)
+		}
+		job, ok := db.jobs[id]
+		if !ok || job.reserved > args[3].(int64) {
+			return driver.RowsAffected(0), nil
+		}
+		job.reserved, job.payload = args[0].(int64), args[1].(string)
+		db.jobs[id] = job
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(query, "UPDATE jobs SET payload"):
+		id := args[2].(string)
+		job, ok := db.jobs[id]
+		if !ok {
+			return driver.RowsAffected(0), nil
+		}
+		job.payload, job.at, job.reserved = args[0].(string), args[1].(int64), 0
+		db.jobs[id] = job
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(query, "DELETE FROM jobs"):
+		return db.delete(db.jobs, args[0].(string)), nil
+	case strings.HasPrefix(query, "DELETE FROM failed_jobs"):
+		return db.delete(db.failed, args[0].(string)), nil
+	}
+	return nil, fmt.Errorf("unexpected statement %q", query)
+}
+
+func (db *fakeDatabase) QueryContext(ctx context.Context, query string, named []driver.NamedValue) (driver.Rows, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, query)
+	args := values(named)
+
+	rows := &fakeRows{}
+	switch {
+	case strings.HasPrefix(query, "SELECT id, payload FROM jobs"):
+		rows.columns = []string{"id", "payload"}
+		var ids []string
+		for id, job := range db.jobs {
+			if job.queue == args[0].(string) && job.at <= args[1].(int64) && job.reserved <= args[2].(int64) {
+				ids = append(ids, id)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return db.jobs[ids[i]].at < db.jobs[ids[j]].at })
+		if len(ids) > 0 {
+			rows.values = [][]driver.Value{{ids[0], db.jobs[ids[0]].payload}}
+		}
+	case strings.HasPrefix(query, "SELECT payload FROM failed_jobs"):
+		rows.columns = []string{"payload"}
+		var ids []string
+		for id := range db.failed {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return db.failed[ids[i]].at < db.failed[ids[j]].at })
+		for _, id := range ids {
+			rows.values = append(rows.values, []driver.Value{db.failed[id].payload})
+		}
+	default:
+		return nil, fmt.Errorf("unexpected query %q", query)
+	}
+	return rows, nil
+}
+
+// delete removes a row by ID.
+func (db *fakeDatabase) delete(table map[string]fakeJob, id string) driver.Result {
+	if _, ok := table[id]; !ok {
+		return driver.RowsAffected(0)
+	}
+	delete(table, id)
+	return driver.RowsAffected(1)
+}
+
+// values returns the values of query arguments.
+func values(named []driver.NamedValue) []driver.Value {
+	args := make([]driver.Value, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+	return args
+}
+
+// fakeRows are the rows of a query to fakeDatabase.
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// fakeDatabases numbers the registered fake databases, as database/sql drivers can't be
+// registered twice under the same name.
+var fakeDatabases int
+
+// openFakeDatabase opens a fresh fakeDatabase through database/sql.
+func openFakeDatabase(t *testing.T) (*sql.DB, *fakeDatabase) {
+	fake := newFakeDatabase()
+	fakeDatabases++
+	name := fmt.Sprintf("fake-%d", fakeDatabases)
+	sql.Register(name, fake)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, fake
+}
+
+func TestSQLDriver(t *testing.T) {
+	db, _ := openFakeDatabase(t)
+	driver := NewSQLDriver(db, "sqlite")
+	if err := driver.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	testDriver(t, driver)
+}
+
+func TestSQLDriverSkipsJobsAnotherWorkerReserved(t *testing.T) {
+	db, fake := openFakeDatabase(t)
+	driver := NewSQLDriver(db, "sqlite")
+	ctx := context.Background()
+	for _, id := range []string{"taken", "free"} {
+		if err := driver.Push(ctx, &Envelope{ID: id, Queue: DefaultQueue}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fake.jobs["free"] = fakeJob{queue: DefaultQueue, payload: fake.jobs["free"].payload, at: 1}
+
+	// Another worker reserves "taken" after this one selected it
+	fake.beforeReserve = func(db *fakeDatabase, id string) {
+		if id == "taken" {
+			job := db.jobs[id]
+			job.reserved = 1 << 62
+			db.jobs[id] = job
+		}
+	}
+	envelope, err := driver.Pop(ctx, DefaultQueue)
+	if err != nil || envelope == nil || envelope.ID != "free" {
+		t.Fatalf("popped %v, %v, want the job nobody reserved", envelope, err)
+	}
+}
+
+func TestSQLDriverQueries(t *testing.T) {
+	tests := []struct {
+		dialect string
+		want    string
+	}{
+		{"sqlite", "UPDATE jobs SET reserved_until = ?, payload = ? WHERE id = ? AND reserved_until <= ?"},
+		{"mysql", "UPDATE jobs SET reserved_until = ?, payload = ? WHERE id = ? AND reserved_until <= ?"},
+		{"postgres", "UPDATE jobs SET reserved_until = $1, payload = $2 WHERE id = $3 AND reserved_until <= $4"},
+	}
+	for _, test := range tests {
+		driver := NewSQLDriver(nil, test.dialect)
+		if got := driver.query("UPDATE %s SET reserved_until = ?, payload = ? WHERE id = ? AND reserved_until <= ?", "jobs"); got != test.want {
+			t.Errorf("%s query is %q, want %q", test.dialect, got, test.want)
+		}
+	}
+}
+
+func TestSQLDriverMigrateIndexesPerDialect(t *testing.T) {
+	for dialect, statements := range map[string]int{"sqlite": 3, "postgres": 3, "mysql": 2} {
+		db, fake := openFakeDatabase(t)
+		if err := NewSQLDriver(db, dialect).Migrate(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if len(fake.statements) != statements {
+			t.Errorf("%s migration ran %d statements, want %d", dialect, len(fake.statements), statements)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Logger receives the progress of workers.
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
+
+// MaxTimeout is the longest Timeout of a worker. It leaves a minute of the reservation to
+// delete, release or fail the job. Timeouts are cooperative: a job that ignores the
+// cancellation of its context keeps running past them, and once its reservation expires the
+// job is handed to a second worker while the first one is still running it.
+const MaxTimeout = DefaultReservation - time.Minute
+
+// Worker pops jobs from one or more queues and processes them.
+type Worker struct {
+	Queue         *Queue
+	Queues        []string      // Queues to work, highest priority first; DefaultQueue if empty
+	Concurrency   int           // Jobs processed at once; 1 if zero
+	Sleep         time.Duration // Wait when the queues are empty; 1s if zero
+	Timeout       time.Duration // Deadline of a single attempt; 60s if zero, at most MaxTimeout
+	StopWhenEmpty bool          // Return once the queues are empty instead of waiting for jobs
+	Logger        Logger
+}
+
+// Run processes jobs until ctx is cancelled, then lets the jobs in progress finish
+// before returning. Cancelling ctx does not cancel running jobs; their Timeout still applies.
+func (w *Worker) Run(ctx context.Context) {
+	concurrency := w.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// loop processes jobs one at a time until ctx is cancelled or, with StopWhenEmpty, the queues are empty.
+func (w *Worker) loop(ctx context.Context) {
+	sleep := w.Sleep
+	if sleep <= 0 {
+		sleep = time.Second
+	}
+
+	for ctx.Err() == nil {
+		envelope, err := w.pop(ctx)
+		if err != nil {
+			w.error(fmt.Sprintf("Popping job: %v", err))
+		}
+		if envelope == nil {
+			if w.StopWhenEmpty && err == nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(sleep):
+			}
+			continue
+		}
+		w.process(envelope)
+	}
+}
+
+// pop reserves the next job from the queues in priority order.
+func (w *Worker) pop(ctx context.Context) (*Envelope, error) {
+	queues := w.Queues
+	if len(queues) == 0 {
+		queues = []string{DefaultQueue}
+	}
+	for _, name := range queues {
+		envelope, err := w.Queue.Driver().Pop(ctx, name)
+		if err != nil || envelope != nil {
+			return envelope, err
+		}
+	}
+	return nil, nil
+}
+
+// process attempts a reserved job, then deletes, releases or fails it.
+func (w *Worker) process(envelope *Envelope) {
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	if timeout > MaxTimeout {
+		timeout = MaxTimeout
+	}
+	driver := w.Queue.Driver()
+
+	// Finish the job even when the worker is stopping
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if envelope.Attempts > envelope.MaxTries {
+		// The driver counted an attempt that never finished, e.g. because its worker died
+		envelope.Attempts = envelope.MaxTries
+		envelope.Error = "the last attempt did not finish"
+		envelope.FailedAt = time.Now()
+		w.error(fmt.Sprintf("Job %s (%s) failed after %d attempts: %s", envelope.Job, envelope.ID, envelope.Attempts, envelope.Error))
+		if err := driver.Fail(context.Background(), envelope); err != nil {
+			w.error(fmt.Sprintf("Failing job %s: %v", envelope.ID, err))
+		}
+		return
+	}
+	w.info(fmt.Sprintf("Processing job %s (%s), attempt %d of %d", envelope.Job, envelope.ID, envelope.Attempts, envelope.MaxTries))
+	start := time.Now()
+
+	job, err := w.Queue.Decode(envelope)
+	if err == nil {
+		err = w.Queue.Process(ctx, job)
+	}
+	if err == nil && ctx.Err() != nil {
+		err = fmt.Errorf("timed out after %v", timeout)
+	}
+
+	if err == nil {
+		w.info(fmt.Sprintf("Processed job %s (%s) in %v", envelope.Job, envelope.ID, time.Since(start).Round(time.Millisecond)))
+		if err := driver.Delete(context.Background(), envelope); err != nil {
+			w.error(fmt.Sprintf("Deleting job %s: %v", envelope.ID, err))
+		}
+		return
+	}
+
+	envelope.Error = err.Error()
+	if envelope.Attempts < envelope.MaxTries {
+		delay := backoff(envelope)
+		envelope.AvailableAt = time.Now().Add(delay)
+		w.error(fmt.Sprintf("Job %s (%s) failed, retrying in %v: %v", envelope.Job, envelope.ID, delay, err))
+		if err := driver.Release(context.Background(), envelope); err != nil {
+			w.error(fmt.Sprintf("Releasing job %s: %v", envelope.ID, err))
+		}
+		return
+	}
+
+	envelope.FailedAt = time.Now()
+	w.error(fmt.Sprintf("Job %s (%s) failed after %d attempts: %v", envelope.Job, envelope.ID, envelope.Attempts, err))
+	if err := driver.Fail(context.Background(), envelope); err != nil {
+		w.error(fmt.Sprintf("Failing job %s: %v", envelope.ID, err))
+	}
+}
+
+// info logs a message if a logger is set.
+func (w *Worker) info(msg string) {
+	if w.Logger != nil {
+		w.Logger.Info(msg)
+	}
+}
+
+// error logs an error message if a logger is set.
+func (w *Worker) error(msg string) {
+	if w.Logger != nil {
+		w.Logger.Error(msg)
+	}
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -46,6 +47,19 @@ func (rl *RateLimiter) AddVisitor(ip string) *time.Ticker {
 	return v.limiter
 }
 
+// SetRate changes the rate and burst. The tickers of existing visitors are reset to the new
+// rate rather than replaced, as requests in flight may still be using them.
+func (rl *RateLimiter) SetRate(rate time.Duration, burst int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.rate = rate
+	rl.burst = burst
+	for _, v := range rl.visitors {
+		v.limiter.Reset(rate)
+	}
+}
+
 // cleanupVisitors removes old visitors from the rate limiter.
 func (rl *RateLimiter) cleanupVisitors() {
 	for {
@@ -60,6 +74,31 @@ func (rl *RateLimiter) cleanupVisitors() {
 	}
 }
 
+// RateLimiter creates a rate limiter from the RATE_LIMIT_INTERVAL and RATE_LIMIT_BURST
+// configuration keys that follows changes when the configuration is reloaded.
+func (k *Kernel) RateLimiter() *RateLimiter {
+	rl := NewRateLimiter(k.rateLimit())
+	k.OnConfigChange(func(change ConfigChange) {
+		if change.Has("RATE_LIMIT_INTERVAL", "RATE_LIMIT_BURST") {
+			rl.SetRate(k.rateLimit())
+		}
+	})
+	return rl
+}
+
+// rateLimit reads the rate limiter settings from the configuration.
+func (k *Kernel) rateLimit() (time.Duration, int) {
+	rate, err := time.ParseDuration(k.ConfigString("RATE_LIMIT_INTERVAL", "100ms"))
+	if err != nil {
+		rate = 100 * time.Millisecond
+	}
+	burst, err := strconv.Atoi(k.ConfigString("RATE_LIMIT_BURST", "1"))
+	if err != nil {
+		burst = 1
+	}
+	return rate, burst
+}
+
 // RateLimitingMiddleware limits the number of requests per client.
 func RateLimitingMiddleware(rateLimiter *RateLimiter) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -0,0 +1,63 @@
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// Injector sets the dependencies of a controller for a request, e.g. from a service container.
+type Injector func(req *http.Request, controller interface{}) error
+
+// SetInjector sets the injector of the controllers of the router and its groups.
+func (r *Router) SetInjector(injector Injector) {
+	r.root().injector = injector
+}
+
+// Action returns a handler calling a method of a controller struct, with the signature of an
+// http.HandlerFunc. Each request gets a copy of the controller, whose dependencies the
+// router's injector sets before the method runs:
+//
+//	users := &controllers.UserController{PerPage: 20}
+//	api.Get("/users", api.Action(users, "Index"))
+//
+// Action panics if controller is not a pointer to a struct or has no such method.
+func (r *Router) Action(controller interface{}, method string) http.HandlerFunc {
+	prototype := reflect.ValueOf(controller)
+	if prototype.Kind() != reflect.Ptr || prototype.IsNil() || prototype.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("Action expects a pointer to a controller struct, got %T", controller))
+	}
+	fn, ok := prototype.Type().MethodByName(method)
+	if !ok || !isAction(fn.Type) {
+		panic(fmt.Sprintf("%T has no method %s(http.ResponseWriter, *http.Request)", controller, method))
+	}
+
+	errorHandler := r.ErrorHandler()
+	return func(w http.ResponseWriter, req *http.Request) {
+		instance := reflect.New(prototype.Elem().Type())
+		instance.Elem().Set(prototype.Elem())
+		if injector := r.root().injector; injector != nil {
+			if err := injector(req, instance.Interface()); err != nil {
+				errorHandler.HandleError(w, req, http.StatusInternalServerError, fmt.Errorf("injecting %T: %w", controller, err))
+				return
+			}
+		}
+		instance.MethodByName(method).Interface().(func(http.ResponseWriter, *http.Request))(w, req)
+	}
+}
+
+// isAction reports whether a method type, receiver included, handles a request.
+func isAction(t reflect.Type) bool {
+	return t.NumIn() == 3 && t.NumOut() == 0 &&
+		t.In(1) == reflect.TypeOf((*http.ResponseWriter)(nil)).Elem() &&
+		t.In(2) == reflect.TypeOf((*http.Request)(nil))
+}
+
+// root returns the router the groups of this router belong to.
+func (r *Router) root() *Router {
+	root := r
+	for root.parentRouter != nil {
+		root = root.parentRouter
+	}
+	return root
+}
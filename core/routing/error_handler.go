@@ -17,8 +17,11 @@ type ErrorHandler struct {
 // NewErrorHandler initializes a new ErrorHandler.
 func NewErrorHandler() *ErrorHandler {
 	// Check if the environment is development or production.
-	isDevMode := os.Getenv("APP_ENV") == "development"
+	return newErrorHandler(os.Getenv("APP_ENV") == "development")
+}
 
+// newErrorHandler initializes an ErrorHandler showing error details in development mode.
+func newErrorHandler(isDevMode bool) *ErrorHandler {
 	// Define the path where custom error views are located.
 	viewPath := "resources/views/errors/"
 
@@ -28,6 +31,20 @@ func NewErrorHandler() *ErrorHandler {
 	}
 }
 
+// SetDevMode sets whether the errors of the router and its groups show their details and a
+// stack trace, instead of reading APP_ENV from the process environment.
+func (r *Router) SetDevMode(enabled bool) {
+	r.root().errors = newErrorHandler(enabled)
+}
+
+// ErrorHandler returns the error handler of the router and its groups.
+func (r *Router) ErrorHandler() *ErrorHandler {
+	if handler := r.root().errors; handler != nil {
+		return handler
+	}
+	return NewErrorHandler()
+}
+
 // HandleError displays the appropriate error page or message.
 func (eh *ErrorHandler) HandleError(w http.ResponseWriter, req *http.Request, statusCode int, err error) {
 	if eh.isDevMode {
@@ -1,14 +1,193 @@
 package routing
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
 
 // Middleware is a function that wraps around an http.Handler to perform additional processing.
 type Middleware func(http.Handler) http.Handler
 
 // ApplyMiddleware applies middleware to a given handler.
+// The first middleware in the list is the outermost one and runs first.
 func ApplyMiddleware(handler http.Handler, middleware ...Middleware) http.Handler {
-	for _, mw := range middleware {
-		handler = mw(handler)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
 	}
 	return handler
 }
+
+// MiddlewareFactory creates middleware from the parameters of a name such as "feature:beta,new-ui".
+type MiddlewareFactory func(params ...string) func(http.Handler) http.Handler
+
+// MiddlewareRegistry holds named middleware aliases, middleware groups and the priority order.
+type MiddlewareRegistry struct {
+	aliases   map[string]Middleware
+	factories map[string]MiddlewareFactory
+	groups    map[string][]string
+	priority  []string
+	version   atomic.Uint64 // Incremented on every change, so routes know to resolve their middleware again
+	mu        sync.RWMutex
+}
+
+// NewMiddlewareRegistry creates an empty MiddlewareRegistry.
+func NewMiddlewareRegistry() *MiddlewareRegistry {
+	return &MiddlewareRegistry{
+		aliases:   make(map[string]Middleware),
+		factories: make(map[string]MiddlewareFactory),
+		groups:    make(map[string][]string),
+	}
+}
+
+// Alias registers middleware under a name.
+func (mr *MiddlewareRegistry) Alias(name string, middleware func(http.Handler) http.Handler) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	mr.aliases[name] = middleware
+	mr.version.Add(1)
+}
+
+// AliasFactory registers parameterized middleware under a name. Routes use it as
+// "name:param1,param2"; the factory receives the parameters.
+func (mr *MiddlewareRegistry) AliasFactory(name string, factory MiddlewareFactory) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	mr.factories[name] = factory
+	mr.version.Add(1)
+}
+
+// Group registers a named group made of aliases or other groups, e.g. "web" or "api".
+func (mr *MiddlewareRegistry) Group(name string, members ...string) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	mr.groups[name] = append([]string{}, members...)
+	mr.version.Add(1)
+}
+
+// SetPriority sets the order named middleware run in, regardless of registration order.
+// Middleware in the priority list runs first, in list order; the rest keeps its registration order.
+func (mr *MiddlewareRegistry) SetPriority(names ...string) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	mr.priority = append([]string{}, names...)
+	mr.version.Add(1)
+}
+
+// Resolve expands names into middleware, skipping excluded aliases and groups and sorting
+// by priority. Names that are not registered are an error, excluded ones included.
+func (mr *MiddlewareRegistry) Resolve(names []string, excluded []string) ([]Middleware, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	skip := make(map[string]bool, len(excluded))
+	for _, name := range excluded {
+		// Excluding a factory by name skips it whatever its parameters
+		if _, ok := mr.factories[name]; ok {
+			skip[name] = true
+			continue
+		}
+		expanded, err := mr.expand(name, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		for _, alias := range expanded {
+			skip[alias] = true
+		}
+	}
+
+	aliases := []string{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		expanded, err := mr.expand(name, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		for _, alias := range expanded {
+			base, _ := splitMiddlewareName(alias)
+			if seen[alias] || skip[alias] || skip[base] {
+				continue
+			}
+			seen[alias] = true
+			aliases = append(aliases, alias)
+		}
+	}
+
+	rank := make(map[string]int, len(mr.priority))
+	for i, name := range mr.priority {
+		rank[name] = i
+	}
+	sort.SliceStable(aliases, func(i, j int) bool {
+		bi, _ := splitMiddlewareName(aliases[i])
+		bj, _ := splitMiddlewareName(aliases[j])
+		ri, iok := rank[bi]
+		rj, jok := rank[bj]
+		if !iok {
+			ri = len(mr.priority)
+		}
+		if !jok {
+			rj = len(mr.priority)
+		}
+		return ri < rj
+	})
+
+	middleware := make([]Middleware, 0, len(aliases))
+	for _, alias := range aliases {
+		if m, ok := mr.aliases[alias]; ok {
+			middleware = append(middleware, m)
+			continue
+		}
+		base, params := splitMiddlewareName(alias)
+		middleware = append(middleware, Middleware(mr.factories[base](params...)))
+	}
+	return middleware, nil
+}
+
+// Version returns a number that changes whenever middleware is registered.
+func (mr *MiddlewareRegistry) Version() uint64 {
+	return mr.version.Load()
+}
+
+// expand flattens a group or alias name into alias names.
+func (mr *MiddlewareRegistry) expand(name string, visiting map[string]bool) ([]string, error) {
+	if _, ok := mr.aliases[name]; ok {
+		return []string{name}, nil
+	}
+	if base, _ := splitMiddlewareName(name); base != name {
+		if _, ok := mr.factories[base]; ok {
+			return []string{name}, nil
+		}
+	}
+
+	members, ok := mr.groups[name]
+	if !ok {
+		return nil, fmt.Errorf("middleware '%s' not registered", name)
+	}
+	if visiting[name] {
+		return nil, fmt.Errorf("middleware group '%s' contains itself", name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	aliases := []string{}
+	for _, member := range members {
+		expanded, err := mr.expand(member, visiting)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, expanded...)
+	}
+	return aliases, nil
+}
+
+// splitMiddlewareName splits "name:a,b" into its name and parameters.
+func splitMiddlewareName(name string) (string, []string) {
+	base, params, found := strings.Cut(name, ":")
+	if !found {
+		return name, nil
+	}
+	return base, strings.Split(params, ",")
+}
@@ -0,0 +1,81 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// order runs middleware around an empty handler and returns the names they added, in
+// the order they ran.
+func order(middleware []Middleware) string {
+	recorder := httptest.NewRecorder()
+	ApplyMiddleware(http.HandlerFunc(ok), middleware...).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	return strings.Join(recorder.Header().Values("X-Middleware"), ",")
+}
+
+func TestMiddlewareRegistryResolve(t *testing.T) {
+	registry := NewMiddlewareRegistry()
+	for _, name := range []string{"session", "csrf", "auth", "log", "throttle"} {
+		registry.Alias(name, header(name))
+	}
+	registry.AliasFactory("feature", func(params ...string) func(http.Handler) http.Handler {
+		return header("feature(" + strings.Join(params, "+") + ")")
+	})
+	registry.Group("web", "session", "csrf")
+	registry.Group("admin", "web", "auth")
+	registry.Group("api", "throttle", "auth")
+
+	tests := []struct {
+		names, excluded []string
+		priority        []string
+		want            string
+	}{
+		{names: []string{"log"}, want: "log"},
+		{names: []string{"web"}, want: "session,csrf"},
+		{names: []string{"admin", "log"}, want: "session,csrf,auth,log"},
+		{names: []string{"web", "admin"}, want: "session,csrf,auth"},
+		{names: []string{"feature:beta,new-ui"}, want: "feature(beta+new-ui)"},
+		{names: []string{"feature:a", "feature:b"}, want: "feature(a),feature(b)"},
+		{names: []string{"admin"}, excluded: []string{"csrf"}, want: "session,auth"},
+		{names: []string{"admin", "log"}, excluded: []string{"web"}, want: "auth,log"},
+		{names: []string{"feature:a", "log"}, excluded: []string{"feature"}, want: "log"},
+		{names: []string{"log", "api", "web"}, priority: []string{"auth", "session"}, want: "auth,session,log,throttle,csrf"},
+		{names: nil, want: ""},
+	}
+	for _, test := range tests {
+		registry.SetPriority(test.priority...)
+		middleware, err := registry.Resolve(test.names, test.excluded)
+		if err != nil {
+			t.Errorf("Resolve(%q, %q): %v", test.names, test.excluded, err)
+			continue
+		}
+		if got := order(middleware); got != test.want {
+			t.Errorf("Resolve(%q, %q) with priority %q ran %q, want %q", test.names, test.excluded, test.priority, got, test.want)
+		}
+	}
+}
+
+func TestMiddlewareRegistryResolveErrors(t *testing.T) {
+	registry := NewMiddlewareRegistry()
+	registry.Alias("auth", header("auth"))
+	registry.Group("loop", "auth", "cycle")
+	registry.Group("cycle", "loop")
+
+	tests := []struct {
+		names, excluded []string
+		want            string
+	}{
+		{names: []string{"missing"}, want: "middleware 'missing' not registered"},
+		{names: []string{"auth:admin"}, want: "middleware 'auth:admin' not registered"},
+		{names: []string{"loop"}, want: "middleware group 'loop' contains itself"},
+		{names: []string{"auth"}, excluded: []string{"typo"}, want: "middleware 'typo' not registered"},
+	}
+	for _, test := range tests {
+		_, err := registry.Resolve(test.names, test.excluded)
+		if err == nil || err.Error() != test.want {
+			t.Errorf("Resolve(%q, %q) returned %v, want %q", test.names, test.excluded, err, test.want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"sync/atomic"
 )
 
 // Route represents an individual route with parameters, middleware, and error handling.
@@ -15,11 +16,34 @@ type Route struct {
 	Middleware   []func(http.Handler) http.Handler
 	Params       map[string]string
 	ErrorHandler http.HandlerFunc // Route-specific error handler
+
+	MiddlewareNames    []string // Named middleware aliases and groups
+	ExcludedMiddleware []string // Named middleware skipped for this route
+
+	compiled atomic.Pointer[compiledRoute] // Handler wrapped in its middleware, see Router.Compile
+}
+
+// compiledRoute is the handler of a route wrapped in its middleware, built from a version of
+// the middleware registry.
+type compiledRoute struct {
+	handler http.Handler
+	version uint64
 }
 
 // NewRoute creates a new route instance with dynamic parameters.
 func NewRoute(method, path string, handler http.HandlerFunc, middleware ...func(http.Handler) http.Handler) *Route {
-	// Convert dynamic parameters to a regex pattern
+	return &Route{
+		Method:      method,
+		Path:        path,
+		Pattern:     compilePattern(path),
+		HandlerFunc: handler,
+		Middleware:  middleware,
+		Params:      make(map[string]string),
+	}
+}
+
+// compilePattern converts dynamic parameters in a path to a regex pattern.
+func compilePattern(path string) *regexp.Regexp {
 	pattern := regexp.MustCompile(`\{([a-zA-Z0-9]+)(:[^}]+)?\}`)
 	regexPath := "^" + pattern.ReplaceAllStringFunc(path, func(m string) string {
 		parts := strings.SplitN(m[1:len(m)-1], ":", 2)
@@ -28,13 +52,19 @@ func NewRoute(method, path string, handler http.HandlerFunc, middleware ...func(
 		}
 		return "([^/]+)"
 	}) + "$"
+	return regexp.MustCompile(regexPath)
+}
 
-	return &Route{
-		Method:      method,
-		Path:        path,
-		Pattern:     regexp.MustCompile(regexPath),
-		HandlerFunc: handler,
-		Middleware:  middleware,
-		Params:      make(map[string]string),
-	}
+// Use assigns named middleware aliases or groups to the route.
+func (route *Route) Use(names ...string) *Route {
+	route.MiddlewareNames = append(route.MiddlewareNames, names...)
+	route.compiled.Store(nil)
+	return route
+}
+
+// WithoutMiddleware excludes named middleware aliases or groups inherited from router groups.
+func (route *Route) WithoutMiddleware(names ...string) *Route {
+	route.ExcludedMiddleware = append(route.ExcludedMiddleware, names...)
+	route.compiled.Store(nil)
+	return route
 }
@@ -1,6 +1,7 @@
 package routing
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -12,6 +13,10 @@ type Router struct {
 	parentRouter  *Router                  // Reference to the parent router, if any
 	prefix        string                   // Prefix for routes in this group
 	middleware    []func(http.Handler) http.Handler
+	names         []string            // Named middleware applied to routes in this group
+	registry      *MiddlewareRegistry // Named middleware shared by the router and its groups
+	injector      Injector            // Sets the dependencies of controllers, on the root router
+	errors        *ErrorHandler       // Handles the errors of the router and its groups, on the root router
 }
 
 // NewRouter initializes a new router.
@@ -19,58 +24,121 @@ func NewRouter() *Router {
 	return &Router{
 		routes:        []*Route{},
 		errorHandlers: make(map[int]http.HandlerFunc),
+		registry:      NewMiddlewareRegistry(),
 	}
 }
 
+// Middleware returns the registry of named middleware shared by the router and its groups.
+func (r *Router) Middleware() *MiddlewareRegistry {
+	return r.registry
+}
+
+// Routes returns the routes registered with the root router.
+func (r *Router) Routes() []*Route {
+	return append([]*Route{}, r.root().routes...)
+}
+
+// Use assigns named middleware aliases or groups to routes added to this router afterwards.
+func (r *Router) Use(names ...string) *Router {
+	r.names = append(r.names, names...)
+	return r
+}
+
 // Group creates a new route group with a common prefix and middleware.
 func (r *Router) Group(prefix string, middleware ...func(http.Handler) http.Handler) *Router {
 	return &Router{
 		parentRouter:  r,
 		prefix:        r.prefix + prefix, // Carry forward any existing prefix
-		middleware:    append(r.middleware, middleware...),
+		middleware:    append(append([]func(http.Handler) http.Handler{}, r.middleware...), middleware...),
+		names:         append([]string{}, r.names...),
 		errorHandlers: r.errorHandlers, // Use the same error handlers as the parent
+		registry:      r.registry,
 	}
 }
 
-// AddRoute registers a new route to the router or its parent.
+// AddRoute registers a new route to the root router.
 func (r *Router) AddRoute(route *Route) {
-	// Apply the group's prefix and middleware
+	// Apply the group's prefix and middleware, which already include those of its parents
 	route.Path = r.prefix + route.Path
-	route.Middleware = append(r.middleware, route.Middleware...)
+	route.Pattern = compilePattern(route.Path)
+	route.Middleware = append(append([]func(http.Handler) http.Handler{}, r.middleware...), route.Middleware...)
+	route.MiddlewareNames = append(append([]string{}, r.names...), route.MiddlewareNames...)
 
-	// If this router has a parent, register the route with the parent
-	if r.parentRouter != nil {
-		r.parentRouter.AddRoute(route)
-	} else {
-		// Otherwise, register the route with this router
-		r.routes = append(r.routes, route)
-	}
+	// Register the route with the root router
+	root := r.root()
+	root.routes = append(root.routes, route)
 }
 
 // Register HTTP methods with optional middleware
-func (r *Router) Get(path string, handler http.HandlerFunc, middleware ...func(http.Handler) http.Handler) {
-	r.AddRoute(NewRoute("GET", path, handler, middleware...))
+func (r *Router) Get(path string, handler http.HandlerFunc, middleware ...func(http.Handler) http.Handler) *Route {
+	route := NewRoute("GET", path, handler, middleware...)
+	r.AddRoute(route)
+	return route
+}
+
+func (r *Router) Post(path string, handler http.HandlerFunc, middleware ...func(http.Handler) http.Handler) *Route {
+	route := NewRoute("POST", path, handler, middleware...)
+	r.AddRoute(route)
+	return route
 }
 
-func (r *Router) Post(path string, handler http.HandlerFunc, middleware ...func(http.Handler) http.Handler) {
-	r.AddRoute(NewRoute("POST", path, handler, middleware...))
+func (r *Router) Put(path string, handler http.HandlerFunc, middleware ...func(http.Handler) http.Handler) *Route {
+	route := NewRoute("PUT", path, handler, middleware...)
+	r.AddRoute(route)
+	return route
 }
 
-func (r *Router) Put(path string, handler http.HandlerFunc, middleware ...func(http.Handler) http.Handler) {
-	r.AddRoute(NewRoute("PUT", path, handler, middleware...))
+func (r *Router) Patch(path string, handler http.HandlerFunc, middleware ...func(http.Handler) http.Handler) *Route {
+	route := NewRoute("PATCH", path, handler, middleware...)
+	r.AddRoute(route)
+	return route
 }
 
-func (r *Router) Patch(path string, handler http.HandlerFunc, middleware ...func(http.Handler) http.Handler) {
-	r.AddRoute(NewRoute("PATCH", path, handler, middleware...))
+func (r *Router) Delete(path string, handler http.HandlerFunc, middleware ...func(http.Handler) http.Handler) *Route {
+	route := NewRoute("DELETE", path, handler, middleware...)
+	r.AddRoute(route)
+	return route
 }
 
-func (r *Router) Delete(path string, handler http.HandlerFunc, middleware ...func(http.Handler) http.Handler) {
-	r.AddRoute(NewRoute("DELETE", path, handler, middleware...))
+// Compile resolves the named middleware of every route and wraps each handler in its
+// middleware, so requests don't resolve it again. It reports routes using middleware that is
+// not registered. Routes added or middleware registered afterwards are compiled on their
+// first request.
+func (r *Router) Compile() error {
+	errs := []error{}
+	for _, route := range r.root().routes {
+		if _, err := r.handler(route); err != nil {
+			errs = append(errs, fmt.Errorf("route %s %s: %w", route.Method, route.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// handler returns the handler of a route wrapped in its middleware, compiling it unless it
+// was compiled with the current middleware registry.
+func (r *Router) handler(route *Route) (http.Handler, error) {
+	version := r.registry.Version()
+	if compiled := route.compiled.Load(); compiled != nil && compiled.version == version {
+		return compiled.handler, nil
+	}
+
+	named, err := r.registry.Resolve(route.MiddlewareNames, route.ExcludedMiddleware)
+	if err != nil {
+		return nil, err
+	}
+
+	// Anonymous middleware wraps named middleware; the first listed runs first
+	handler := ApplyMiddleware(http.HandlerFunc(route.HandlerFunc), named...)
+	for i := len(route.Middleware) - 1; i >= 0; i-- {
+		handler = route.Middleware[i](handler)
+	}
+	route.compiled.Store(&compiledRoute{handler: handler, version: version})
+	return handler, nil
 }
 
 // ServeHTTP implements the http.Handler interface.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	errorHandler := NewErrorHandler() // Initialize the centralized error handler
+	errorHandler := r.ErrorHandler() // The centralized error handler
 
 	for _, route := range r.routes {
 		if route.Method == req.Method && route.Pattern.MatchString(req.URL.Path) {
@@ -83,9 +151,10 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 					}
 				}
 
-				handler := http.Handler(http.HandlerFunc(route.HandlerFunc))
-				for _, mw := range route.Middleware {
-					handler = mw(handler)
+				handler, err := r.handler(route)
+				if err != nil {
+					errorHandler.HandleError(w, req, http.StatusInternalServerError, err)
+					return
 				}
 
 				// Error handling for route-specific errors
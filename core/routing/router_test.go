@@ -0,0 +1,108 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// header returns middleware adding its name to the X-Middleware response header.
+func header(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Add("X-Middleware", name)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+func ok(w http.ResponseWriter, req *http.Request) {}
+
+// serve requests path from the router and returns the middleware that ran.
+func serve(t *testing.T, router *Router, path string) string {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET %s returned %d: %s", path, recorder.Code, recorder.Body)
+	}
+	return strings.Join(recorder.Header().Values("X-Middleware"), ",")
+}
+
+func TestCompileReportsUnknownMiddleware(t *testing.T) {
+	router := NewRouter()
+	router.Middleware().Alias("auth", header("auth"))
+	router.Get("/known", ok).Use("auth")
+	router.Get("/typo", ok).Use("atuh")
+	router.Get("/excluded", ok).WithoutMiddleware("missing")
+
+	err := router.Compile()
+	if err == nil {
+		t.Fatal("compiling succeeded, want the unknown middleware reported")
+	}
+	for _, want := range []string{"GET /typo: middleware 'atuh' not registered", "GET /excluded: middleware 'missing' not registered"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't mention %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "/known") {
+		t.Errorf("error %q mentions a valid route", err)
+	}
+}
+
+func TestCompiledRoutesResolveMiddlewareOnce(t *testing.T) {
+	router := NewRouter()
+	created := 0
+	router.Middleware().AliasFactory("tag", func(params ...string) func(http.Handler) http.Handler {
+		created++
+		return header(strings.Join(params, "+"))
+	})
+	router.Get("/", ok).Use("tag:a,b")
+	if err := router.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if got := serve(t, router, "/"); got != "a+b" {
+			t.Fatalf("middleware %q ran, want a+b", got)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("factory called %d times, want once", created)
+	}
+
+	// Registering middleware afterwards compiles the route again
+	router.Middleware().SetPriority("tag")
+	serve(t, router, "/")
+	if created != 2 {
+		t.Fatalf("factory called %d times after a registry change, want twice", created)
+	}
+}
+
+func TestWithoutMiddlewareExcludesGroups(t *testing.T) {
+	router := NewRouter()
+	registry := router.Middleware()
+	registry.Alias("session", header("session"))
+	registry.Alias("csrf", header("csrf"))
+	registry.Alias("log", header("log"))
+	registry.AliasFactory("feature", func(params ...string) func(http.Handler) http.Handler {
+		return header("feature")
+	})
+	registry.Group("web", "session", "csrf")
+
+	web := router.Group("").Use("web", "log", "feature:beta")
+	web.Get("/page", ok)
+	web.Get("/webhook", ok).WithoutMiddleware("web")
+	web.Get("/plain", ok).WithoutMiddleware("csrf", "feature")
+
+	for path, want := range map[string]string{
+		"/page":    "session,csrf,log,feature",
+		"/webhook": "log,feature",
+		"/plain":   "session,log",
+	} {
+		if got := serve(t, router, path); got != want {
+			t.Errorf("GET %s ran %q, want %q", path, got, want)
+		}
+	}
+}
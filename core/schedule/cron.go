@@ -0,0 +1,173 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month, month and day of week.
+type Cron struct {
+	expression string
+	minutes    [60]bool
+	hours      [24]bool
+	days       [32]bool
+	months     [13]bool
+	weekdays   [7]bool
+	anyDay     bool // The day of month field is "*"
+	anyWeekday bool // The day of week field is "*"
+}
+
+// cronField describes the range of one cron field.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // Names accepted in place of numbers, starting at min
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// ParseCron parses a cron expression such as "*/5 * * * *" or "0 3 * * mon-fri".
+// Fields accept "*", numbers, names of months and weekdays, ranges, lists and steps.
+// Day of week 7 is Sunday, like 0.
+func ParseCron(expression string) (*Cron, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression '%s' must have %d fields", expression, len(cronFields))
+	}
+
+	c := &Cron{expression: strings.Join(fields, " "), anyDay: fields[2] == "*", anyWeekday: fields[4] == "*"}
+	for i, field := range cronFields {
+		values, err := field.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression '%s': %w", expression, err)
+		}
+		for _, value := range values {
+			switch i {
+			case 0:
+				c.minutes[value] = true
+			case 1:
+				c.hours[value] = true
+			case 2:
+				c.days[value] = true
+			case 3:
+				c.months[value] = true
+			case 4:
+				c.weekdays[value%7] = true
+			}
+		}
+	}
+	return c, nil
+}
+
+// String returns the normalized expression.
+func (c *Cron) String() string {
+	return c.expression
+}
+
+// Matches reports whether the minute of t matches the expression.
+func (c *Cron) Matches(t time.Time) bool {
+	return c.minutes[t.Minute()] && c.hours[t.Hour()] && c.months[t.Month()] && c.dayMatches(t)
+}
+
+// Next returns the first minute after t that matches the expression, or the zero time if
+// none does within five years (e.g. "0 0 30 2 *").
+func (c *Cron) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		switch {
+		case !c.months[next.Month()]:
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+		case !c.dayMatches(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+		case !c.hours[next.Hour()]:
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+		case !c.minutes[next.Minute()]:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the day of t matches the day of month and day of week fields.
+// Like cron, a restricted day of month and day of week match if either does.
+func (c *Cron) dayMatches(t time.Time) bool {
+	day, weekday := c.days[t.Day()], c.weekdays[t.Weekday()]
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// parse returns the values selected by one field of an expression.
+func (f cronField) parse(text string) ([]int, error) {
+	values := []int{}
+	for _, part := range strings.Split(text, ",") {
+		rangeText, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %s field '%s'", f.name, part)
+			}
+			rangeText, step = part[:i], n
+		}
+
+		low, high := f.min, f.max
+		if f.name == "day of week" {
+			high = 6 // "*" covers Sunday once
+		}
+		if rangeText != "*" {
+			bounds := strings.SplitN(rangeText, "-", 2)
+			var err error
+			if low, err = f.value(bounds[0]); err != nil {
+				return nil, err
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = f.value(bounds[1]); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				high = f.max // "5/15" means from 5 to the end in steps of 15
+			}
+			if high < low {
+				return nil, fmt.Errorf("invalid range in %s field '%s'", f.name, part)
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// value parses a number or name within the range of the field.
+func (f cronField) value(text string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(text, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("invalid %s '%s', expected %d-%d", f.name, text, f.min, f.max)
+	}
+	return n, nil
+}
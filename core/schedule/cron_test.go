@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expression string
+		matches    []string
+		misses     []string
+	}{
+		{"* * * * *", []string{"2024-03-04 05:06"}, nil},
+		{"*/15 * * * *", []string{"2024-03-04 05:00", "2024-03-04 05:45"}, []string{"2024-03-04 05:10"}},
+		{"5/20 * * * *", []string{"2024-03-04 05:05", "2024-03-04 05:45"}, []string{"2024-03-04 05:00"}},
+		{"0 9-17/4 * * *", []string{"2024-03-04 09:00", "2024-03-04 13:00", "2024-03-04 17:00"}, []string{"2024-03-04 11:00"}},
+		{"30 3 1,15 * *", []string{"2024-03-01 03:30", "2024-03-15 03:30"}, []string{"2024-03-02 03:30"}},
+		{"0 0 * jan,JUL *", []string{"2024-01-10 00:00", "2024-07-10 00:00"}, []string{"2024-02-10 00:00"}},
+		{"0 3 * * mon-fri", []string{"2024-03-04 03:00", "2024-03-08 03:00"}, []string{"2024-03-09 03:00"}},
+		{"0 0 * * 7", []string{"2024-03-10 00:00"}, []string{"2024-03-09 00:00"}},
+		{"0 0 13 * fri", []string{"2024-03-13 00:00", "2024-03-08 00:00"}, []string{"2024-03-12 00:00"}}, // Either day field matches
+		{"  0   0  * * *  ", []string{"2024-03-04 00:00"}, []string{"2024-03-04 00:01"}},
+	}
+	for _, test := range tests {
+		cron, err := ParseCron(test.expression)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", test.expression, err)
+			continue
+		}
+		for _, at := range test.matches {
+			if !cron.Matches(parseMinute(t, at)) {
+				t.Errorf("%q doesn't match %s", test.expression, at)
+			}
+		}
+		for _, at := range test.misses {
+			if cron.Matches(parseMinute(t, at)) {
+				t.Errorf("%q matches %s", test.expression, at)
+			}
+		}
+	}
+}
+
+func TestParseCronRejectsInvalidExpressions(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"10-5 * * * *",
+		"* * * foo *",
+	} {
+		if _, err := ParseCron(expression); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", expression)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		expression, after, want string
+	}{
+		{"* * * * *", "2024-03-04 05:06", "2024-03-04 05:07"},
+		{"0 * * * *", "2024-03-04 05:06", "2024-03-04 06:00"},
+		{"0 0 * * *", "2024-12-31 23:59", "2025-01-01 00:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"0 9 * * mon", "2024-03-04 09:00", "2024-03-11 09:00"},
+		{"0 0 30 2 *", "2024-03-04 00:00", ""},
+	}
+	for _, test := range tests {
+		cron, err := ParseCron(test.expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next := cron.Next(parseMinute(t, test.after))
+		got := ""
+		if !next.IsZero() {
+			got = next.Format("2006-01-02 15:04")
+		}
+		if got != test.want {
+			t.Errorf("%q after %s is %q, want %q", test.expression, test.after, got, test.want)
+		}
+	}
+}
+
+// parseMinute parses a time such as "2024-03-04 05:06" in UTC.
+func parseMinute(t *testing.T, text string) time.Time {
+	parsed, err := time.Parse("2006-01-02 15:04", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
@@ -0,0 +1,184 @@
+package schedule
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LockStore holds the locks that keep a task from overlapping with itself or running on
+// more than one instance. Stores shared between instances, such as a database or Redis,
+// make OnOneServer work across hosts.
+type LockStore interface {
+	// Acquire takes the lock if it is free or expired, reporting whether it was taken.
+	Acquire(key string, ttl time.Duration) (bool, error)
+	// Release frees the lock.
+	Release(key string) error
+}
+
+// MemoryLockStore keeps locks in memory, which only covers a single process.
+type MemoryLockStore struct {
+	locks map[string]time.Time
+	mu    sync.Mutex
+}
+
+// NewMemoryLockStore creates an empty in-memory lock store.
+func NewMemoryLockStore() *MemoryLockStore {
+	return &MemoryLockStore{locks: make(map[string]time.Time)}
+}
+
+// Acquire takes the lock if it is free or expired.
+func (s *MemoryLockStore) Acquire(key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if expires, ok := s.locks[key]; ok && time.Now().Before(expires) {
+		return false, nil
+	}
+	s.locks[key] = time.Now().Add(ttl)
+	return true, nil
+}
+
+// Release frees the lock.
+func (s *MemoryLockStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.locks, key)
+	return nil
+}
+
+// FileLockStore keeps locks as files in a directory, which covers every process on the
+// host or sharing the directory.
+type FileLockStore struct {
+	dir  string
+	held map[string]string // Content of the lock files this store created, by key
+	mu   sync.Mutex
+}
+
+// NewFileLockStore creates a lock store in dir, e.g. "storage/framework/schedule".
+func NewFileLockStore(dir string) *FileLockStore {
+	return &FileLockStore{dir: dir, held: make(map[string]string)}
+}
+
+// Acquire takes the lock by creating its file, replacing the file if it has expired.
+func (s *FileLockStore) Acquire(key string, ttl time.Duration) (bool, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return false, err
+	}
+
+	// Write the expiry and a token to a temporary file and link it into place, so the lock
+	// file never exists without its content
+	token := make([]byte, 8)
+	rand.Read(token)
+	content := strconv.FormatInt(time.Now().Add(ttl).UnixNano(), 10) + " " + hex.EncodeToString(token)
+	temp, err := os.CreateTemp(s.dir, ".lock-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(temp.Name())
+	_, err = temp.WriteString(content)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, err
+	}
+
+	path := s.path(key)
+	for attempt := 0; attempt < 3; attempt++ {
+		err := os.Link(temp.Name(), path)
+		if err == nil {
+			s.hold(key, content)
+			return true, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return false, err
+		}
+
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue // Released in the meantime
+		}
+		if err != nil {
+			return false, err
+		}
+		if !lockExpired(data) {
+			return false, nil
+		}
+
+		// Move the expired lock aside under a name of its own before linking again. Only one
+		// of the processes taking it over at once wins the rename, and only one of them wins
+		// the link that follows.
+		tombstone := temp.Name() + ".expired"
+		if err := os.Rename(path, tombstone); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return false, err
+		}
+		moved, err := os.ReadFile(tombstone)
+		if err == nil && string(moved) != string(data) {
+			// Another process took the lock over since it was read, so put its lock back
+			os.Link(tombstone, path)
+			os.Remove(tombstone)
+			return false, nil
+		}
+		os.Remove(tombstone)
+		if err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// Release frees the lock by removing its file, unless another process took the lock over
+// after it expired.
+func (s *FileLockStore) Release(key string) error {
+	s.mu.Lock()
+	content, held := s.held[key]
+	delete(s.held, key)
+	s.mu.Unlock()
+
+	path := s.path(key)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if held && string(data) != content {
+		return nil
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// hold remembers the content of a lock file this store created.
+func (s *FileLockStore) hold(key, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.held[key] = content
+}
+
+// lockExpired reports whether the content of a lock file is past its expiry. Unreadable
+// content counts as expired, so a damaged lock doesn't block its task forever.
+func lockExpired(data []byte) bool {
+	expiry, _, _ := strings.Cut(string(data), " ")
+	until, err := strconv.ParseInt(expiry, 10, 64)
+	return err != nil || time.Now().UnixNano() >= until
+}
+
+// path returns the file of a lock.
+func (s *FileLockStore) path(key string) string {
+	return filepath.Join(s.dir, strings.NewReplacer("/", "-", ":", "-", " ", "-").Replace(key)+".lock")
+}
@@ -0,0 +1,122 @@
+package schedule
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testLockStore(t *testing.T, locks LockStore) {
+	if acquired, err := locks.Acquire("report", time.Minute); err != nil || !acquired {
+		t.Fatalf("acquiring a free lock returned %v, %v", acquired, err)
+	}
+	if acquired, err := locks.Acquire("report", time.Minute); err != nil || acquired {
+		t.Fatalf("acquiring a held lock returned %v, %v", acquired, err)
+	}
+	if acquired, err := locks.Acquire("backup", time.Minute); err != nil || !acquired {
+		t.Fatalf("acquiring another lock returned %v, %v", acquired, err)
+	}
+	if err := locks.Release("report"); err != nil {
+		t.Fatal(err)
+	}
+	if acquired, err := locks.Acquire("report", time.Minute); err != nil || !acquired {
+		t.Fatalf("acquiring a released lock returned %v, %v", acquired, err)
+	}
+
+	if acquired, err := locks.Acquire("expiring", time.Millisecond); err != nil || !acquired {
+		t.Fatalf("acquiring a free lock returned %v, %v", acquired, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if acquired, err := locks.Acquire("expiring", time.Minute); err != nil || !acquired {
+		t.Fatalf("acquiring an expired lock returned %v, %v", acquired, err)
+	}
+	if err := locks.Release("missing"); err != nil {
+		t.Fatalf("releasing a free lock: %v", err)
+	}
+}
+
+func TestMemoryLockStore(t *testing.T) {
+	testLockStore(t, NewMemoryLockStore())
+}
+
+func TestFileLockStore(t *testing.T) {
+	testLockStore(t, NewFileLockStore(t.TempDir()))
+}
+
+func TestFileLockStoreKeepsLocksTakenOverFromAnotherProcess(t *testing.T) {
+	dir := t.TempDir()
+	first, second := NewFileLockStore(dir), NewFileLockStore(dir)
+
+	if acquired, _ := first.Acquire("report", time.Millisecond); !acquired {
+		t.Fatal("first store didn't acquire the free lock")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if acquired, _ := second.Acquire("report", time.Minute); !acquired {
+		t.Fatal("second store didn't take over the expired lock")
+	}
+
+	// The first store finishing late must not free the lock it lost
+	if err := first.Release("report"); err != nil {
+		t.Fatal(err)
+	}
+	if acquired, _ := first.Acquire("report", time.Minute); acquired {
+		t.Fatal("the lock was freed by the store that lost it")
+	}
+	if err := second.Release("report"); err != nil {
+		t.Fatal(err)
+	}
+	if acquired, _ := first.Acquire("report", time.Minute); !acquired {
+		t.Fatal("the lock is still held after its owner released it")
+	}
+}
+
+func TestFileLockStoreTakesOverDamagedLocks(t *testing.T) {
+	dir := t.TempDir()
+	locks := NewFileLockStore(dir)
+	if err := os.WriteFile(filepath.Join(dir, "report.lock"), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if acquired, err := locks.Acquire("report", time.Minute); err != nil || !acquired {
+		t.Fatalf("acquiring a damaged lock returned %v, %v", acquired, err)
+	}
+}
+
+func TestFileLockStoreHandsAnExpiredLockToOneProcess(t *testing.T) {
+	dir := t.TempDir()
+	for round := 0; round < 50; round++ {
+		if err := os.WriteFile(filepath.Join(dir, "report.lock"), []byte("1 expired"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		start := make(chan struct{})
+		acquired := make(chan bool, 8)
+		var wg sync.WaitGroup
+		for i := 0; i < cap(acquired); i++ {
+			wg.Add(1)
+			go func(locks *FileLockStore) {
+				defer wg.Done()
+				<-start
+				ok, err := locks.Acquire("report", time.Minute)
+				if err != nil {
+					t.Error(err)
+				}
+				acquired <- ok
+			}(NewFileLockStore(dir))
+		}
+		close(start)
+		wg.Wait()
+		close(acquired)
+
+		holders := 0
+		for ok := range acquired {
+			if ok {
+				holders++
+			}
+		}
+		if holders != 1 {
+			t.Fatalf("round %d: %d stores acquired the expired lock, want 1", round, holders)
+		}
+	}
+}
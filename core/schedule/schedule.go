@@ -0,0 +1,245 @@
+// Package schedule runs recurring tasks on cron expressions.
+package schedule
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lock lifetimes. A task whose overlap lock outlives overlapExpiry is assumed to have crashed.
+const (
+	overlapExpiry   = 24 * time.Hour
+	oneServerExpiry = 55 * time.Second // Less than a minute so the next run can take it
+)
+
+// Job is the work of a scheduled task.
+type Job func(ctx context.Context) error
+
+// Logger receives the output of scheduled tasks.
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
+
+// Scheduler holds the scheduled tasks and runs them when they are due.
+type Scheduler struct {
+	tasks    []*Task
+	locks    LockStore
+	location *time.Location
+	logger   Logger
+	mu       sync.RWMutex
+}
+
+// NewScheduler creates a scheduler using local time and in-memory locks.
+func NewScheduler() *Scheduler {
+	return &Scheduler{locks: NewMemoryLockStore(), location: time.Local}
+}
+
+// SetLockStore sets the store of overlap and one-server locks.
+func (s *Scheduler) SetLockStore(locks LockStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locks = locks
+}
+
+// SetLocation sets the timezone of tasks that don't set their own.
+func (s *Scheduler) SetLocation(location *time.Location) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.location = location
+}
+
+// SetLogger sets the logger that receives task output.
+func (s *Scheduler) SetLogger(logger Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logger = logger
+}
+
+// Call schedules a function. Task names must be unique; they key the task's locks.
+// The task runs every minute until a frequency is set.
+func (s *Scheduler) Call(name string, job Job) *Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task := &Task{name: name, job: job}
+	task.Cron("* * * * *")
+	s.tasks = append(s.tasks, task)
+	return task
+}
+
+// Exec schedules an external command, logging its output line by line.
+func (s *Scheduler) Exec(name string, command string, args ...string) *Task {
+	task := s.Call(name, func(ctx context.Context) error {
+		output, err := exec.CommandContext(ctx, command, args...).CombinedOutput()
+		scanner := bufio.NewScanner(bytes.NewReader(output))
+		for scanner.Scan() {
+			s.info(fmt.Sprintf("[%s] %s", name, scanner.Text()))
+		}
+		return err
+	})
+	return task.Description(strings.Join(append([]string{command}, args...), " "))
+}
+
+// Tasks returns the scheduled tasks in the order they were added.
+func (s *Scheduler) Tasks() []*Task {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*Task{}, s.tasks...)
+}
+
+// Location returns the timezone of a task.
+func (s *Scheduler) Location(task *Task) *time.Location {
+	if task.location != nil {
+		return task.location
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.location
+}
+
+// NextRun returns when a task is next due after t, or the zero time if it never is.
+func (s *Scheduler) NextRun(task *Task, t time.Time) time.Time {
+	if task.err != nil {
+		return time.Time{}
+	}
+	return task.cron.Next(t.In(s.Location(task)))
+}
+
+// Due returns the tasks due in the minute of t.
+func (s *Scheduler) Due(t time.Time) []*Task {
+	due := []*Task{}
+	for _, task := range s.Tasks() {
+		if task.err == nil && task.cron.Matches(t.In(s.Location(task))) {
+			due = append(due, task)
+		}
+	}
+	return due
+}
+
+// RunDue runs the tasks due in the minute of t concurrently and waits for them.
+// The errors of failed tasks are joined.
+func (s *Scheduler) RunDue(ctx context.Context, t time.Time) error {
+	for _, task := range s.Tasks() {
+		if task.err != nil {
+			s.error(fmt.Sprintf("Scheduled task %s is invalid: %v", task.name, task.err))
+		}
+	}
+
+	due := s.Due(t)
+	errs := make([]error, len(due))
+	var wg sync.WaitGroup
+	for i, task := range due {
+		wg.Add(1)
+		go func(i int, task *Task) {
+			defer wg.Done()
+			errs[i] = s.RunTask(ctx, task)
+		}(i, task)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// RunTask runs a task now, honoring its overlap and one-server locks.
+func (s *Scheduler) RunTask(ctx context.Context, task *Task) error {
+	s.mu.RLock()
+	locks := s.locks
+	s.mu.RUnlock()
+
+	if task.oneServer {
+		acquired, err := locks.Acquire(task.name+".server", oneServerExpiry)
+		if err != nil {
+			return s.fail(task, fmt.Errorf("acquiring lock: %w", err))
+		}
+		if !acquired {
+			s.info(fmt.Sprintf("Skipping scheduled task %s: running on another server", task.name))
+			return nil
+		}
+	}
+	if task.withoutOverlapping {
+		acquired, err := locks.Acquire(task.name+".overlap", overlapExpiry)
+		if err != nil {
+			return s.fail(task, fmt.Errorf("acquiring lock: %w", err))
+		}
+		if !acquired {
+			s.info(fmt.Sprintf("Skipping scheduled task %s: previous run still in progress", task.name))
+			return nil
+		}
+		defer locks.Release(task.name + ".overlap")
+	}
+
+	s.info(fmt.Sprintf("Running scheduled task %s", task.name))
+	start := time.Now()
+	if err := call(ctx, task.job); err != nil {
+		return s.fail(task, err)
+	}
+	s.info(fmt.Sprintf("Finished scheduled task %s in %v", task.name, time.Since(start).Round(time.Millisecond)))
+	return nil
+}
+
+// Run runs due tasks at the start of every minute until ctx is cancelled, then waits for
+// running tasks to finish. A long task does not delay the tasks of the next minute.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		now := time.Now()
+		timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case tick := <-timer.C:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.RunDue(ctx, tick)
+			}()
+		}
+	}
+}
+
+// fail logs and returns the error of a task.
+func (s *Scheduler) fail(task *Task, err error) error {
+	err = fmt.Errorf("scheduled task %s failed: %w", task.name, err)
+	s.error(err.Error())
+	return err
+}
+
+// info logs a message if a logger is set.
+func (s *Scheduler) info(msg string) {
+	s.mu.RLock()
+	logger := s.logger
+	s.mu.RUnlock()
+	if logger != nil {
+		logger.Info(msg)
+	}
+}
+
+// error logs an error message if a logger is set.
+func (s *Scheduler) error(msg string) {
+	s.mu.RLock()
+	logger := s.logger
+	s.mu.RUnlock()
+	if logger != nil {
+		logger.Error(msg)
+	}
+}
+
+// call runs a job, turning a panic into an error.
+func call(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job(ctx)
+}
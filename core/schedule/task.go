@@ -0,0 +1,172 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Task is a scheduled job. Its methods set the frequency and options fluently:
+//
+//	scheduler.Call("reports:send", SendReports).DailyAt("03:00").Timezone("Europe/Ljubljana").OnOneServer()
+//
+// A method given an invalid value records the error, which keeps the task from running
+// and is reported by schedule:list.
+type Task struct {
+	name               string
+	description        string
+	job                Job
+	cron               *Cron
+	location           *time.Location
+	withoutOverlapping bool
+	oneServer          bool
+	err                error
+}
+
+// Name returns the name of the task.
+func (t *Task) Name() string {
+	return t.name
+}
+
+// Expression returns the cron expression of the task.
+func (t *Task) Expression() string {
+	if t.cron == nil {
+		return ""
+	}
+	return t.cron.String()
+}
+
+// Err returns the error recorded while configuring the task, if any.
+func (t *Task) Err() error {
+	return t.err
+}
+
+// Summary returns the description of the task, or its name if it has none.
+func (t *Task) Summary() string {
+	if t.description != "" {
+		return t.description
+	}
+	return t.name
+}
+
+// Overlapping reports whether runs of the task may overlap.
+func (t *Task) Overlapping() bool {
+	return !t.withoutOverlapping
+}
+
+// RunsOnOneServer reports whether only one instance runs the task each time it is due.
+func (t *Task) RunsOnOneServer() bool {
+	return t.oneServer
+}
+
+// Description sets a human-readable description shown by schedule:list.
+func (t *Task) Description(description string) *Task {
+	t.description = description
+	return t
+}
+
+// Cron sets the frequency with a cron expression.
+func (t *Task) Cron(expression string) *Task {
+	cron, err := ParseCron(expression)
+	if err != nil {
+		t.err = err
+		return t
+	}
+	t.cron = cron
+	return t
+}
+
+// EveryMinute runs the task every minute.
+func (t *Task) EveryMinute() *Task { return t.Cron("* * * * *") }
+
+// EveryFiveMinutes runs the task every five minutes.
+func (t *Task) EveryFiveMinutes() *Task { return t.Cron("*/5 * * * *") }
+
+// EveryTenMinutes runs the task every ten minutes.
+func (t *Task) EveryTenMinutes() *Task { return t.Cron("*/10 * * * *") }
+
+// EveryFifteenMinutes runs the task every fifteen minutes.
+func (t *Task) EveryFifteenMinutes() *Task { return t.Cron("*/15 * * * *") }
+
+// EveryThirtyMinutes runs the task every thirty minutes.
+func (t *Task) EveryThirtyMinutes() *Task { return t.Cron("*/30 * * * *") }
+
+// Hourly runs the task at the start of every hour.
+func (t *Task) Hourly() *Task { return t.Cron("0 * * * *") }
+
+// HourlyAt runs the task every hour at the given minute.
+func (t *Task) HourlyAt(minute int) *Task { return t.Cron(fmt.Sprintf("%d * * * *", minute)) }
+
+// Daily runs the task every day at midnight.
+func (t *Task) Daily() *Task { return t.Cron("0 0 * * *") }
+
+// DailyAt runs the task every day at the given "HH:MM" time.
+func (t *Task) DailyAt(at string) *Task {
+	return t.at(at, "* * *")
+}
+
+// Weekdays runs the task Monday to Friday at the given "HH:MM" time.
+func (t *Task) Weekdays(at string) *Task {
+	return t.at(at, "* * 1-5")
+}
+
+// Weekly runs the task every Sunday at midnight.
+func (t *Task) Weekly() *Task { return t.Cron("0 0 * * 0") }
+
+// WeeklyOn runs the task every week on the given day at the given "HH:MM" time.
+func (t *Task) WeeklyOn(day time.Weekday, at string) *Task {
+	return t.at(at, fmt.Sprintf("* * %d", day))
+}
+
+// Monthly runs the task on the first day of every month at midnight.
+func (t *Task) Monthly() *Task { return t.Cron("0 0 1 * *") }
+
+// MonthlyOn runs the task every month on the given day at the given "HH:MM" time.
+func (t *Task) MonthlyOn(day int, at string) *Task {
+	return t.at(at, fmt.Sprintf("%d * *", day))
+}
+
+// Timezone evaluates the frequency in the given IANA timezone, e.g. "Europe/Ljubljana".
+func (t *Task) Timezone(name string) *Task {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.err = fmt.Errorf("invalid timezone '%s': %w", name, err)
+		return t
+	}
+	t.location = location
+	return t
+}
+
+// WithoutOverlapping skips a run while the previous one is still in progress.
+func (t *Task) WithoutOverlapping() *Task {
+	t.withoutOverlapping = true
+	return t
+}
+
+// OnOneServer runs the task on a single instance each time it is due. The instances must
+// share the scheduler's lock store.
+func (t *Task) OnOneServer() *Task {
+	t.oneServer = true
+	return t
+}
+
+// at sets a frequency at an "HH:MM" time followed by the day, month and weekday fields.
+func (t *Task) at(at string, days string) *Task {
+	parts := strings.Split(at, ":")
+	if len(parts) != 2 {
+		t.err = fmt.Errorf("invalid time '%s', expected HH:MM", at)
+		return t
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		t.err = fmt.Errorf("invalid time '%s', expected HH:MM", at)
+		return t
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		t.err = fmt.Errorf("invalid time '%s', expected HH:MM", at)
+		return t
+	}
+	return t.Cron(fmt.Sprintf("%d %d %s", minute, hour, days))
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"icepeak/core/schedule"
+)
+
+// ScheduleLockDir holds the lock files of scheduled tasks.
+const ScheduleLockDir = "storage/framework/schedule"
+
+// WithScheduler sets whether StartServer runs scheduled tasks in-process. Disable it when
+// the host runs schedule:run from cron instead.
+func WithScheduler(enabled bool) KernelOption {
+	return func(k *Kernel) {
+		k.runScheduler = enabled
+	}
+}
+
+// Schedule returns the task scheduler of this kernel.
+func (k *Kernel) Schedule() *schedule.Scheduler {
+	return k.scheduler
+}
+
+// configureScheduler sets up the scheduler with the SCHEDULE_TIMEZONE configuration key,
+// file locks shared by the processes on this host, and the kernel logger.
+func (k *Kernel) configureScheduler() {
+	k.scheduler.SetLockStore(schedule.NewFileLockStore(ScheduleLockDir))
+	k.scheduler.SetLogger(kernelLogger{k})
+	if name := k.ConfigString("SCHEDULE_TIMEZONE", ""); name != "" {
+		if location, err := time.LoadLocation(name); err == nil {
+			k.scheduler.SetLocation(location)
+		}
+	}
+}
+
+// startScheduler runs the scheduled tasks in the background until the returned function
+// is called, which waits for running tasks to finish.
+func (k *Kernel) startScheduler() (stop func()) {
+	if !k.runScheduler || len(k.scheduler.Tasks()) == 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		k.scheduler.Run(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// validateTimezone checks that a configuration value names a known timezone.
+func validateTimezone(value interface{}) error {
+	if _, err := time.LoadLocation(value.(string)); err != nil {
+		return fmt.Errorf("unknown timezone %q", value)
+	}
+	return nil
+}
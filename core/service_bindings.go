@@ -0,0 +1,129 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// BindTo binds the interface I to the implementation Impl, so consumers ask for the interface
+// and get the service bound to Impl, with its lifetime:
+//
+//	core.Singleton[*FileLogger](services, newFileLogger)
+//	core.BindTo[core.Logger, *FileLogger](services)
+//
+// It fails if Impl does not implement I.
+func BindTo[I, Impl any](sc *ServiceContainer, options ...BindOption) error {
+	iface, impl := typeOf[I](), typeOf[Impl]()
+	if !impl.AssignableTo(iface) {
+		return fmt.Errorf("%s does not implement %s", impl, iface)
+	}
+	Bind[I](sc, func(c *ServiceContainer) (I, error) {
+		var zero I
+		service, err := c.resolve(serviceKey{typ: impl})
+		if err != nil || service == nil {
+			return zero, err
+		}
+		return service.(I), nil
+	}, options...)
+	sc.dependsOn(serviceKey{typ: iface, name: newBindOptions(options).name}, serviceKey{typ: impl})
+	return nil
+}
+
+// WhenNeeds gives the consumer C its own implementation of the dependency D, instead of the
+// one bound to D, when C is resolved from the container:
+//
+//	core.WhenNeeds[*BillingService, core.Logger](services, func(c *core.ServiceContainer) (core.Logger, error) {
+//		return core.MakeNamed[core.Logger](c, "audit")
+//	})
+//
+// The factory runs on every resolution of D by C; bind the implementation it returns as a
+// singleton to share it.
+func WhenNeeds[C, D any](sc *ServiceContainer, factory func(c *ServiceContainer) (D, error)) {
+	consumer, key := typeOf[C](), serviceKey{typ: typeOf[D]()}
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	if sc.contextual[consumer] == nil {
+		sc.contextual[consumer] = make(map[serviceKey]func(c *ServiceContainer) (interface{}, error))
+	}
+	sc.contextual[consumer][key] = func(c *ServiceContainer) (interface{}, error) {
+		return factory(c)
+	}
+}
+
+// WhenNeedsNamed gives the consumer C the implementation of the dependency D bound with
+// Named(name) when C is resolved from the container.
+func WhenNeedsNamed[C, D any](sc *ServiceContainer, name string) {
+	WhenNeeds[C, D](sc, func(c *ServiceContainer) (D, error) {
+		return MakeNamed[D](c, name)
+	})
+}
+
+// WithTags tags a binding, so it is resolved with the other services sharing a tag by Tagged.
+func WithTags(tags ...string) BindOption {
+	return func(o *bindOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// Tag tags the string services registered under a name.
+func (sc *ServiceContainer) Tag(name string, tags ...string) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	sc.tag(serviceKey{name: name}, tags)
+}
+
+// tag adds a service to tags, keeping the order services were tagged in.
+// It must be called with the lock held.
+func (sc *ServiceContainer) tag(key serviceKey, tags []string) {
+tags:
+	for _, tag := range tags {
+		for _, tagged := range sc.tags[tag] {
+			if tagged == key {
+				continue tags
+			}
+		}
+		sc.tags[tag] = append(sc.tags[tag], key)
+	}
+}
+
+// tagged returns the services with a tag.
+func (sc *ServiceContainer) tagged(tag string) []serviceKey {
+	sc.lock.RLock()
+	defer sc.lock.RUnlock()
+	return append([]serviceKey{}, sc.tags[tag]...)
+}
+
+// Tagged resolves every service with a tag, in the order they were tagged.
+func (sc *ServiceContainer) Tagged(tag string) ([]interface{}, error) {
+	keys := sc.tagged(tag)
+	services := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		service, err := sc.resolve(key)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+// MakeTagged resolves every service with a tag as a T, e.g. every exporter:
+//
+//	exporters, err := core.MakeTagged[Exporter](services, "exporter")
+//
+// It fails if a tagged service is not a T.
+func MakeTagged[T any](sc *ServiceContainer, tag string) ([]T, error) {
+	services, err := sc.Tagged(tag)
+	if err != nil {
+		return nil, err
+	}
+	typed := make([]T, len(services))
+	for i, service := range services {
+		t, ok := service.(T)
+		if !ok {
+			return nil, fmt.Errorf("service tagged '%s' is a %s, not a %s", tag, reflect.TypeOf(service), typeOf[T]())
+		}
+		typed[i] = t
+	}
+	return typed, nil
+}
@@ -4,67 +4,524 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 )
 
+// serviceKey identifies a service: string services by name, typed bindings by their type
+// and an optional name.
+type serviceKey struct {
+	typ  reflect.Type
+	name string
+}
+
+func (k serviceKey) String() string {
+	switch {
+	case k.typ == nil:
+		return k.name
+	case k.name == "":
+		return k.typ.String()
+	default:
+		return fmt.Sprintf("%s (%s)", k.typ, k.name)
+	}
+}
+
+// Lifetime is how long an instance of a service is reused.
+type Lifetime int
+
+// Service lifetimes.
+const (
+	LifetimeTransient Lifetime = iota // A new instance on every resolution
+	LifetimeSingleton                 // One instance shared by the whole application
+	LifetimeScoped                    // One instance per scope, e.g. per request
+)
+
+func (l Lifetime) String() string {
+	switch l {
+	case LifetimeSingleton:
+		return "singleton"
+	case LifetimeScoped:
+		return "scoped"
+	default:
+		return "transient"
+	}
+}
+
 // ServiceContainer is the core of the DI system in Icepeak
 type ServiceContainer struct {
-	services   map[string]interface{}        // Holds the actual service instances
-	factories  map[string]func() interface{} // Holds factory functions for lazy loading services
-	singletons map[string]bool               // Tracks which services are singletons
-	lock       sync.RWMutex                  // Ensures thread-safe access
+	*containerState
+	scope    *serviceScope // Instances of scoped services, nil outside a scope
+	path     []serviceKey  // Services being resolved when the container is passed to a factory
+	creating *creation     // Innermost shared instance being created on the path
+}
+
+// containerState is shared by a container, its scopes and the views of them passed to factories.
+type containerState struct {
+	factories    map[serviceKey]func(c *ServiceContainer) (interface{}, error)                  // Holds factory functions for lazy loading services
+	lifetimes    map[serviceKey]Lifetime                                                        // Tracks how long instances are reused
+	instances    *instanceCache                                                                 // Holds the singleton instances
+	contextual   map[reflect.Type]map[serviceKey]func(c *ServiceContainer) (interface{}, error) // Dependencies overridden for a consumer type
+	dependencies map[serviceKey][]serviceKey                                                    // Parameters of the constructor of each service
+	external     map[serviceKey]bool                                                            // Instances owned by the caller, never disposed
+	extenders    map[serviceKey][]extender                                                      // Decorators of each service, in order
+	tags         map[string][]serviceKey                                                        // Services with each tag, in tagging order
+	chains       map[uint64]*factoryChain                                                       // Innermost Register factory running on each goroutine
+	lock         sync.RWMutex                                                                   // Ensures thread-safe access, including to scopes
+}
+
+// instanceCache holds the instances of singletons, or of the scoped services of one scope.
+type instanceCache struct {
+	services map[serviceKey]interface{}
+	creating map[serviceKey]*creation
+	created  []interface{} // Instances in creation order, disposed in reverse
+}
+
+// newInstanceCache creates an empty cache.
+func newInstanceCache() *instanceCache {
+	return &instanceCache{services: make(map[serviceKey]interface{}), creating: make(map[serviceKey]*creation)}
+}
+
+// creation is an instance being created, which other resolutions wait for.
+type creation struct {
+	key        serviceKey
+	done       chan struct{}
+	service    interface{}
+	err        error
+	waitingFor *creation // Creation its factory waits for, guarded by the container lock
+}
+
+// factoryChain is a Register factory running on a goroutine. Such factories resolve their
+// dependencies through the container they close over rather than the one carrying the
+// resolution path, so the path is looked up by goroutine instead.
+type factoryChain struct {
+	view      *ServiceContainer // Container carrying the path to the service the factory creates
+	outer     *factoryChain     // Register factory running further up the same goroutine
+	goroutine uint64
+	cycle     *CycleError // First cycle the resolutions of the factory ran into
 }
 
 // NewServiceContainer initializes a new ServiceContainer
 func NewServiceContainer() *ServiceContainer {
-	return &ServiceContainer{
-		services:   make(map[string]interface{}),
-		factories:  make(map[string]func() interface{}),
-		singletons: make(map[string]bool),
-	}
+	return &ServiceContainer{containerState: &containerState{
+		factories:    make(map[serviceKey]func(c *ServiceContainer) (interface{}, error)),
+		lifetimes:    make(map[serviceKey]Lifetime),
+		instances:    newInstanceCache(),
+		contextual:   make(map[reflect.Type]map[serviceKey]func(c *ServiceContainer) (interface{}, error)),
+		dependencies: make(map[serviceKey][]serviceKey),
+		external:     make(map[serviceKey]bool),
+		extenders:    make(map[serviceKey][]extender),
+		tags:         make(map[string][]serviceKey),
+		chains:       make(map[uint64]*factoryChain),
+	}}
+}
+
+// CycleError reports services that depend on each other, e.g. a -> b -> a.
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Path, " -> ")
 }
 
 // Register registers a new service with an optional singleton flag
 func (sc *ServiceContainer) Register(name string, factory func() interface{}, isSingleton bool) {
+	lifetime := LifetimeTransient
+	if isSingleton {
+		lifetime = LifetimeSingleton
+	}
+	sc.bind(serviceKey{name: name}, func(c *ServiceContainer) (interface{}, error) {
+		chain := c.enterFactory()
+		defer c.leaveFactory(chain)
+		service := factory()
+		if chain.cycle != nil {
+			// The factory can't return the error, so report the cycle it ran into for it
+			return nil, chain.cycle
+		}
+		return service, nil
+	}, lifetime)
+}
+
+// enterFactory records the container passed to a Register factory as the resolution path of
+// the goroutine running it.
+func (sc *ServiceContainer) enterFactory() *factoryChain {
+	id := goroutineID()
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	chain := &factoryChain{view: sc, outer: sc.chains[id], goroutine: id}
+	sc.chains[id] = chain
+	return chain
+}
+
+// leaveFactory restores the resolution path of the goroutine once a Register factory returns.
+func (sc *ServiceContainer) leaveFactory(chain *factoryChain) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	if chain.outer != nil {
+		sc.chains[chain.goroutine] = chain.outer
+	} else {
+		delete(sc.chains, chain.goroutine)
+	}
+}
+
+// runningFactory returns the innermost Register factory running on the calling goroutine,
+// if any.
+func (sc *ServiceContainer) runningFactory() *factoryChain {
+	sc.lock.RLock()
+	defer sc.lock.RUnlock()
+	if len(sc.chains) == 0 {
+		return nil
+	}
+	return sc.chains[goroutineID()]
+}
+
+// goroutineID returns the ID of the calling goroutine, read from the header of its stack
+// trace, e.g. "goroutine 18 [running]:".
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	fields := strings.Fields(strings.TrimPrefix(string(buf), "goroutine "))
+	if len(fields) == 0 {
+		return 0
+	}
+	id, _ := strconv.ParseUint(fields[0], 10, 64)
+	return id
+}
+
+// bind stores the factory of a service, replacing any singleton instance already created.
+func (sc *ServiceContainer) bind(key serviceKey, factory func(c *ServiceContainer) (interface{}, error), lifetime Lifetime, tags ...string) {
 	sc.lock.Lock()
 	defer sc.lock.Unlock()
+	sc.register(key, factory, lifetime, tags)
+}
+
+// register stores the factory of a service. It must be called with the lock held.
+func (sc *ServiceContainer) register(key serviceKey, factory func(c *ServiceContainer) (interface{}, error), lifetime Lifetime, tags []string) {
+	sc.factories[key] = factory
+	sc.lifetimes[key] = lifetime
+	delete(sc.dependencies, key)
+	delete(sc.external, key)
+	delete(sc.instances.services, key)
+	delete(sc.instances.creating, key)
+	sc.tag(key, tags)
+}
 
-	sc.factories[name] = factory
-	sc.singletons[name] = isSingleton
+// Instance registers an instance created elsewhere as a singleton under a name. It belongs
+// to the caller, so Shutdown does not dispose it.
+func (sc *ServiceContainer) Instance(name string, instance interface{}) {
+	key := serviceKey{name: name}
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	sc.register(key, func(c *ServiceContainer) (interface{}, error) {
+		return instance, nil
+	}, LifetimeSingleton, nil)
+	sc.external[key] = true
 }
 
 // Resolve resolves a service by name, with support for lazy loading and singletons
 func (sc *ServiceContainer) Resolve(name string) (interface{}, error) {
-	sc.lock.RLock()
-	service, exists := sc.services[name]
-	sc.lock.RUnlock()
+	return sc.resolve(serviceKey{name: name})
+}
 
-	if exists {
-		return service, nil // Return the already resolved service
+// resolve creates or returns the instance of a service, reporting dependency cycles
+// instead of recursing forever. The resolution path is carried by the view of the container
+// passed to factories.
+func (sc *ServiceContainer) resolve(key serviceKey) (interface{}, error) {
+	if len(sc.path) == 0 {
+		if chain := sc.runningFactory(); chain != nil {
+			// Continue the path of the Register factory resolving through the container it closes over
+			view := &ServiceContainer{containerState: sc.containerState, scope: sc.scope, path: chain.view.path, creating: chain.view.creating}
+			service, err := view.resolve(key)
+			var cycle *CycleError
+			if errors.As(err, &cycle) && chain.cycle == nil {
+				chain.cycle = cycle
+			}
+			return service, err
+		}
 	}
 
 	sc.lock.RLock()
-	factory, exists := sc.factories[name]
+	if len(sc.path) > 0 && len(sc.contextual) > 0 {
+		if override := sc.contextual[sc.path[len(sc.path)-1].typ][key]; override != nil {
+			sc.lock.RUnlock()
+			if cycle := cycleIn(sc.path, key); cycle != nil {
+				return nil, cycle
+			}
+			return sc.create(key, override, LifetimeTransient, sc.pathTo(key), sc.creating)
+		}
+	}
+	if sc.scope != nil && sc.scope.instances != nil {
+		if service, put := sc.scope.instances.services[key]; put && sc.lifetimes[key] != LifetimeSingleton {
+			sc.lock.RUnlock()
+			return service, nil // Return the instance of the scope
+		}
+	}
+	factory, exists := sc.factories[key]
+	lifetime := sc.lifetimes[key]
+	cache, err := sc.cacheFor(key, lifetime)
+	if cache != nil {
+		if service, cached := cache.services[key]; cached {
+			sc.lock.RUnlock()
+			return service, nil // Return the already resolved service
+		}
+	}
 	sc.lock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
 
+	if cycle := cycleIn(sc.path, key); cycle != nil {
+		return nil, cycle
+	}
 	if !exists {
-		return nil, errors.New(fmt.Sprintf("Service '%s' not registered", name))
+		if len(sc.path) > 0 {
+			return nil, fmt.Errorf("Service '%s' not registered (required by %s)", key, strings.Join(keyNames(sc.path), " -> "))
+		}
+		return nil, fmt.Errorf("Service '%s' not registered", key)
+	}
+	if cache == nil {
+		return sc.create(key, factory, lifetime, sc.pathTo(key), sc.creating)
 	}
 
-	// Instantiate the service using its factory function
-	service = factory()
+	// Create a shared instance once; concurrent resolutions wait for the one creating it
+	sc.lock.Lock()
+	if service, cached := cache.services[key]; cached {
+		sc.lock.Unlock()
+		return service, nil
+	}
+	if c, creating := cache.creating[key]; creating {
+		return sc.wait(c)
+	}
+	c := &creation{key: key, done: make(chan struct{}), err: fmt.Errorf("creating service '%s' panicked", key)}
+	cache.creating[key] = c
+	sc.lock.Unlock()
 
-	// If it's a singleton, store the instance for future use
-	if sc.singletons[name] {
+	defer func() {
 		sc.lock.Lock()
-		sc.services[name] = service
+		// Keep the instance unless the service was registered again or the scope closed meanwhile
+		if cache.creating[key] == c {
+			delete(cache.creating, key)
+			if c.err == nil {
+				cache.services[key] = c.service
+				if !sc.external[key] {
+					cache.created = append(cache.created, c.service)
+				}
+			}
+		}
 		sc.lock.Unlock()
+		close(c.done)
+	}()
+	c.service, c.err = sc.create(key, factory, lifetime, sc.pathTo(key), c)
+	return c.service, c.err
+}
+
+// wait waits for another resolution to create an instance, unless the factory creating it
+// waits, directly or through other creations, for the one this resolution belongs to:
+// factories needing each other would otherwise wait forever. It must be called with the
+// lock held, which it releases.
+func (sc *ServiceContainer) wait(c *creation) (interface{}, error) {
+	if sc.creating != nil {
+		chain := sc.pathTo(c.key)
+		for next := c.waitingFor; next != nil; next = next.waitingFor {
+			chain = append(chain, next.key)
+			if next == sc.creating {
+				sc.lock.Unlock()
+				return nil, cycleOf(chain)
+			}
+		}
+		if c == sc.creating {
+			sc.lock.Unlock()
+			return nil, cycleOf(chain)
+		}
+		sc.creating.waitingFor = c
+	}
+	sc.lock.Unlock()
+
+	if sc.creating != nil {
+		defer func() {
+			sc.lock.Lock()
+			sc.creating.waitingFor = nil
+			sc.lock.Unlock()
+		}()
 	}
+	<-c.done
+	return c.service, c.err
+}
 
+// cacheFor returns the cache holding the instances of a service, nil for transient services.
+// It must be called with the lock held.
+func (sc *ServiceContainer) cacheFor(key serviceKey, lifetime Lifetime) (*instanceCache, error) {
+	switch lifetime {
+	case LifetimeSingleton:
+		return sc.instances, nil
+	case LifetimeScoped:
+		if sc.scope == nil {
+			return nil, fmt.Errorf("Service '%s' %w, e.g. core.RequestScope(r)", key, ErrScopeRequired)
+		}
+		if sc.scope.instances == nil {
+			return nil, fmt.Errorf("Service '%s' resolved from a closed scope", key)
+		}
+		return sc.scope.instances, nil
+	}
+	return nil, nil
+}
+
+// create calls the factory of a service with a view of the container carrying the path,
+// then its extenders. Singletons get a view without the scope, so they can't capture
+// request-scoped services.
+func (sc *ServiceContainer) create(key serviceKey, factory func(c *ServiceContainer) (interface{}, error), lifetime Lifetime, path []serviceKey, creating *creation) (interface{}, error) {
+	view := &ServiceContainer{containerState: sc.containerState, scope: sc.scope, path: path, creating: creating}
+	if lifetime == LifetimeSingleton {
+		view.scope = nil
+	}
+	service, err := factory(view)
+	if err == nil {
+		service, err = sc.decorate(key, service, view)
+	}
+	if err != nil {
+		var cycle *CycleError
+		if errors.As(err, &cycle) {
+			return nil, cycle
+		}
+		if lifetime == LifetimeSingleton && errors.Is(err, ErrScopeRequired) {
+			// Not a missing scope: the singleton would outlive the scoped instance
+			return nil, fmt.Errorf("singleton '%s' must not depend on scoped services: %v", key, err)
+		}
+		return nil, fmt.Errorf("resolving service '%s': %w", key, err)
+	}
 	return service, nil
 }
 
-// AutoResolve attempts to resolve dependencies dynamically based on the type
+// pathTo returns the resolution path extended with a service.
+func (sc *ServiceContainer) pathTo(key serviceKey) []serviceKey {
+	return append(sc.path[:len(sc.path):len(sc.path)], key)
+}
+
+// cycleIn returns the cycle resolving the service would close on the path, if any.
+func cycleIn(path []serviceKey, key serviceKey) *CycleError {
+	for i, resolving := range path {
+		if resolving == key {
+			return &CycleError{Path: keyNames(append(path[i:len(path):len(path)], key))}
+		}
+	}
+	return nil
+}
+
+// cycleOf returns the cycle ending a chain of services, starting at the first occurrence of
+// its last service.
+func cycleOf(chain []serviceKey) *CycleError {
+	last := chain[len(chain)-1]
+	for i, key := range chain[:len(chain)-1] {
+		if key == last {
+			return &CycleError{Path: keyNames(chain[i:])}
+		}
+	}
+	return &CycleError{Path: keyNames(chain)}
+}
+
+// keyNames returns the names of service keys.
+func keyNames(keys []serviceKey) []string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.String()
+	}
+	return names
+}
+
+// Verify checks the wiring of the container, reporting all missing dependencies, dependency
+// cycles and singleton factory errors at once. It resolves the singletons, which are created
+// once anyway and disposed by Shutdown, and checks the constructor parameters of the other
+// services without creating instances (see Provide); transient and scoped services bound to
+// plain factories are checked when they are resolved.
+func (sc *ServiceContainer) Verify() error {
+	sc.lock.RLock()
+	keys := []serviceKey{}
+	for key := range sc.factories {
+		keys = append(keys, key)
+	}
+	sc.lock.RUnlock()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	errs, seen := []error{}, map[string]bool{}
+	for _, key := range keys {
+		if err := sc.verify(key); err != nil && !errors.Is(err, ErrScopeRequired) && !seen[err.Error()] {
+			seen[err.Error()] = true
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// verify resolves a singleton or checks the dependencies of another service.
+func (sc *ServiceContainer) verify(key serviceKey) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("resolving service '%s' panicked: %v", key, r)
+		}
+	}()
+
+	sc.lock.RLock()
+	lifetime := sc.lifetimes[key]
+	sc.lock.RUnlock()
+	if lifetime == LifetimeSingleton {
+		_, err = sc.resolve(key)
+		return err
+	}
+	return sc.verifyDependencies([]serviceKey{key}, map[serviceKey]bool{})
+}
+
+// verifyDependencies checks that the declared dependencies of the last service on the path
+// are registered and don't lead back to the path, skipping the services already checked.
+func (sc *ServiceContainer) verifyDependencies(path []serviceKey, checked map[serviceKey]bool) error {
+	key := path[len(path)-1]
+	if checked[key] {
+		return nil
+	}
+	sc.lock.RLock()
+	dependencies := sc.dependencies[key]
+	sc.lock.RUnlock()
+
+	for _, dependency := range dependencies {
+		if cycle := cycleIn(path, dependency); cycle != nil {
+			return cycle
+		}
+		if !sc.provides(dependency) {
+			return fmt.Errorf("Service '%s' not registered (required by %s)", dependency, strings.Join(keyNames(path), " -> "))
+		}
+		if err := sc.verifyDependencies(append(path[:len(path):len(path)], dependency), checked); err != nil {
+			return err
+		}
+	}
+	checked[key] = true
+	return nil
+}
+
+// Has reports whether a service is registered under the name.
+func (sc *ServiceContainer) Has(name string) bool {
+	sc.lock.RLock()
+	defer sc.lock.RUnlock()
+	_, exists := sc.factories[serviceKey{name: name}]
+	return exists
+}
+
+// AutoResolve sets the fields of the struct target points to that have an "inject" tag:
+// `inject:"name"` resolves the service registered under the name and `inject:""` the service
+// bound to the type of the field. The "optional" option leaves the field unchanged when no
+// such service is registered, e.g. `inject:",optional"`:
+//
+//	type UserController struct {
+//		Repo  *UserRepo   `inject:""`
+//		Cache Cache       `inject:",optional"`
+//		Log   core.Logger `inject:"logger"`
+//	}
+//
+// Untagged struct fields, embedded ones included, and embedded pointers to structs are
+// injected recursively. Every field that can't be injected is reported.
 func (sc *ServiceContainer) AutoResolve(target interface{}) error {
 	value := reflect.ValueOf(target)
 	if value.Kind() != reflect.Ptr || value.IsNil() {
@@ -75,30 +532,104 @@ func (sc *ServiceContainer) AutoResolve(target interface{}) error {
 	if elem.Kind() != reflect.Struct {
 		return errors.New("target must point to a struct")
 	}
+	visited := map[uintptr]bool{value.Pointer(): true}
+	return errors.Join(sc.injectFields(elem, elem.Type().String(), visited)...)
+}
 
+// injectFields injects the tagged fields of a struct and of the structs it contains,
+// following each embedded pointer once.
+func (sc *ServiceContainer) injectFields(elem reflect.Value, path string, visited map[uintptr]bool) []error {
+	errs := []error{}
 	for i := 0; i < elem.NumField(); i++ {
 		field := elem.Field(i)
 		fieldType := elem.Type().Field(i)
+		name := path + "." + fieldType.Name
 
-		// Check for an "inject" tag
-		tag := fieldType.Tag.Get("inject")
-		if tag == "" || !field.CanSet() {
+		tag, tagged := fieldType.Tag.Lookup("inject")
+		if !tagged {
+			switch {
+			case field.Kind() == reflect.Struct:
+				errs = append(errs, sc.injectFields(field, name, visited)...)
+			case fieldType.Anonymous && field.Kind() == reflect.Ptr && !field.IsNil() && field.Elem().Kind() == reflect.Struct:
+				if !visited[field.Pointer()] {
+					visited[field.Pointer()] = true
+					errs = append(errs, sc.injectFields(field.Elem(), name, visited)...)
+				}
+			}
 			continue
 		}
+		if err := sc.injectField(field, tag); err != nil {
+			errs = append(errs, fmt.Errorf("field %s (%s): %w", name, fieldType.Type, err))
+		}
+	}
+	return errs
+}
+
+// injectField sets a field from the service its "inject" tag names.
+func (sc *ServiceContainer) injectField(field reflect.Value, tag string) error {
+	name, options, _ := strings.Cut(tag, ",")
+	optional := false
+	if options != "" {
+		for _, option := range strings.Split(options, ",") {
+			if option != "optional" {
+				return fmt.Errorf("unknown inject option '%s'", option)
+			}
+			optional = true
+		}
+	}
+	if !field.CanSet() {
+		return errors.New("field is unexported")
+	}
 
-		// Resolve the service by the tag name
-		service, err := sc.Resolve(tag)
+	key := serviceKey{typ: field.Type(), name: name}
+	if name != "" {
+		key.typ = nil
+	}
+	if optional && !sc.provides(key) {
+		return nil
+	}
+	if key.typ != nil {
+		value, err := sc.resolveType(key.typ)
 		if err != nil {
 			return err
 		}
-
-		// Set the field with the resolved service
-		field.Set(reflect.ValueOf(service))
+		field.Set(value)
+		return nil
 	}
 
+	service, err := sc.resolve(key)
+	if err != nil {
+		return err
+	}
+	if service == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	value := reflect.ValueOf(service)
+	if !value.Type().AssignableTo(field.Type()) {
+		return fmt.Errorf("service '%s' is a %s, not assignable to the field", name, value.Type())
+	}
+	field.Set(value)
 	return nil
 }
 
+// provides reports whether the container, or its scope, has a service.
+func (sc *ServiceContainer) provides(key serviceKey) bool {
+	if key.typ == containerType {
+		return true
+	}
+	sc.lock.RLock()
+	defer sc.lock.RUnlock()
+	if _, exists := sc.factories[key]; exists {
+		return true
+	}
+	if sc.scope != nil && sc.scope.instances != nil {
+		_, put := sc.scope.instances.services[key]
+		return put
+	}
+	return false
+}
+
 // RegisterSingleton is a helper method to register a singleton service
 func (sc *ServiceContainer) RegisterSingleton(name string, factory func() interface{}) {
 	sc.Register(name, factory, true)
@@ -108,3 +639,108 @@ func (sc *ServiceContainer) RegisterSingleton(name string, factory func() interf
 func (sc *ServiceContainer) RegisterLazy(name string, factory func() interface{}) {
 	sc.Register(name, factory, false)
 }
+
+// BindOption configures a typed binding.
+type BindOption func(*bindOptions)
+
+// bindOptions holds the settings of a typed binding.
+type bindOptions struct {
+	name string
+	tags []string
+}
+
+// newBindOptions applies options to the default settings.
+func newBindOptions(options []BindOption) bindOptions {
+	settings := bindOptions{}
+	for _, option := range options {
+		option(&settings)
+	}
+	return settings
+}
+
+// Named binds one of several implementations of the same type, resolved with MakeNamed.
+func Named(name string) BindOption {
+	return func(o *bindOptions) {
+		o.name = name
+	}
+}
+
+// Bind registers a factory for the type T, creating a new instance on every resolution.
+// Bind interfaces to let consumers ask for the interface rather than an implementation:
+//
+//	core.Bind[core.Logger](services, func(c *core.ServiceContainer) (core.Logger, error) {
+//		return core.NewDefaultLogger("INFO", "stdout"), nil
+//	})
+func Bind[T any](sc *ServiceContainer, factory func(c *ServiceContainer) (T, error), options ...BindOption) {
+	bindTyped(sc, factory, LifetimeTransient, options)
+}
+
+// Singleton registers a factory for the type T whose instance is created once and shared.
+func Singleton[T any](sc *ServiceContainer, factory func(c *ServiceContainer) (T, error), options ...BindOption) {
+	bindTyped(sc, factory, LifetimeSingleton, options)
+}
+
+// bindTyped registers a typed factory.
+func bindTyped[T any](sc *ServiceContainer, factory func(c *ServiceContainer) (T, error), lifetime Lifetime, options []BindOption) {
+	settings := newBindOptions(options)
+	key := serviceKey{typ: typeOf[T](), name: settings.name}
+	sc.bind(key, func(c *ServiceContainer) (interface{}, error) {
+		return factory(c)
+	}, lifetime, settings.tags...)
+}
+
+// Make resolves the service bound to the type T.
+func Make[T any](sc *ServiceContainer) (T, error) {
+	return MakeNamed[T](sc, "")
+}
+
+// MakeNamed resolves the implementation of the type T bound with Named(name).
+func MakeNamed[T any](sc *ServiceContainer, name string) (T, error) {
+	var zero T
+	service, err := sc.resolve(serviceKey{typ: typeOf[T](), name: name})
+	if err != nil || service == nil {
+		return zero, err
+	}
+	return service.(T), nil
+}
+
+// MustMake resolves the service bound to the type T, panicking if it can't. Use it where a
+// missing service is a programming error, e.g. while wiring the application.
+func MustMake[T any](sc *ServiceContainer) T {
+	service, err := Make[T](sc)
+	if err != nil {
+		panic(err)
+	}
+	return service
+}
+
+// HasType reports whether a service is bound to the type T.
+func HasType[T any](sc *ServiceContainer) bool {
+	sc.lock.RLock()
+	defer sc.lock.RUnlock()
+	_, exists := sc.factories[serviceKey{typ: typeOf[T]()}]
+	return exists
+}
+
+// typeOf returns the type T, including interface types.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// bindService binds the type T to the string service name, so typed and named lookups
+// return the same instance and replacing the named service affects both.
+func bindService[T any](sc *ServiceContainer, name string) {
+	Bind[T](sc, func(c *ServiceContainer) (T, error) {
+		var zero T
+		service, err := c.Resolve(name)
+		if err != nil {
+			return zero, err
+		}
+		typed, ok := service.(T)
+		if !ok {
+			return zero, fmt.Errorf("service '%s' is a %T, not a %s", name, service, typeOf[T]())
+		}
+		return typed, nil
+	})
+	sc.dependsOn(serviceKey{typ: typeOf[T]()}, serviceKey{name: name})
+}
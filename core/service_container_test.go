@@ -0,0 +1,540 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// resolveConcurrently resolves a service from many goroutines released at once.
+func resolveConcurrently(t *testing.T, n int, resolve func() (interface{}, error)) []interface{} {
+	t.Helper()
+	start := make(chan struct{})
+	results := make([]interface{}, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			service, err := resolve()
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = service
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return results
+}
+
+func TestSingletonCreatedOnceUnderConcurrentResolution(t *testing.T) {
+	type logFile struct{ id int32 }
+	var created int32
+
+	sc := NewServiceContainer()
+	sc.RegisterSingleton("log", func() interface{} {
+		time.Sleep(5 * time.Millisecond) // Widen the window for a second creation
+		return &logFile{id: atomic.AddInt32(&created, 1)}
+	})
+
+	results := resolveConcurrently(t, 200, func() (interface{}, error) {
+		return sc.Resolve("log")
+	})
+	if created != 1 {
+		t.Fatalf("factory called %d times, want 1", created)
+	}
+	for _, result := range results {
+		if result != results[0] {
+			t.Fatalf("got instances %v and %v, want one", result, results[0])
+		}
+	}
+}
+
+func TestTypedSingletonCreatedOnceUnderConcurrentResolution(t *testing.T) {
+	type repo struct{}
+	var created int32
+
+	sc := NewServiceContainer()
+	Singleton[*repo](sc, func(c *ServiceContainer) (*repo, error) {
+		atomic.AddInt32(&created, 1)
+		time.Sleep(5 * time.Millisecond)
+		return &repo{}, nil
+	})
+	sc.Provide(func(r *repo) string { return "uses repo" })
+
+	resolveConcurrently(t, 200, func() (interface{}, error) {
+		if _, err := Make[string](sc); err != nil {
+			return nil, err
+		}
+		return Make[*repo](sc)
+	})
+	if created != 1 {
+		t.Fatalf("factory called %d times, want 1", created)
+	}
+}
+
+func TestFailedSingletonIsRetried(t *testing.T) {
+	var calls int32
+	sc := NewServiceContainer()
+	Singleton[int](sc, func(c *ServiceContainer) (int, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			time.Sleep(5 * time.Millisecond)
+			return 0, errors.New("unavailable")
+		}
+		return 42, nil
+	})
+
+	resolveConcurrently(t, 50, func() (interface{}, error) {
+		Make[int](sc) // Waiters share the failure of the first creation
+		return nil, nil
+	})
+	if n, err := Make[int](sc); err != nil || n != 42 {
+		t.Fatalf("got %v, %v after a failed creation, want 42", n, err)
+	}
+}
+
+func TestRegisterWhileResolving(t *testing.T) {
+	sc := NewServiceContainer()
+	sc.RegisterSingleton("config", func() interface{} { return 1 })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			sc.RegisterSingleton("config", func() interface{} { return i })
+		}(i)
+		go func() {
+			defer wg.Done()
+			if _, err := sc.Resolve("config"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSingletonsNeedingEachOtherAcrossGoroutinesDoNotDeadlock(t *testing.T) {
+	type a struct{}
+	type b struct{}
+	aStarted, bStarted := make(chan struct{}), make(chan struct{})
+
+	sc := NewServiceContainer()
+	Singleton[*a](sc, func(c *ServiceContainer) (*a, error) {
+		close(aStarted)
+		<-bStarted
+		_, err := Make[*b](c)
+		return &a{}, err
+	})
+	Singleton[*b](sc, func(c *ServiceContainer) (*b, error) {
+		close(bStarted)
+		<-aStarted
+		_, err := Make[*a](c)
+		return &b{}, err
+	})
+
+	errs := make(chan error, 2)
+	go func() {
+		_, err := Make[*a](sc)
+		errs <- err
+	}()
+	go func() {
+		_, err := Make[*b](sc)
+		errs <- err
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			var cycle *CycleError
+			if !errors.As(err, &cycle) {
+				t.Fatalf("got error %v, want a dependency cycle", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("resolution deadlocked")
+		}
+	}
+}
+
+func TestScopedServiceCreatedOncePerScope(t *testing.T) {
+	type tx struct{ id int }
+	sc := NewServiceContainer()
+	Scoped[*tx](sc, func(c *ServiceContainer) (*tx, error) {
+		return &tx{}, nil
+	})
+
+	first, second := sc.NewScope(), sc.NewScope()
+	a, _ := Make[*tx](first)
+	b, _ := Make[*tx](first)
+	c, _ := Make[*tx](second)
+	if a == nil || a != b || a == c {
+		t.Fatalf("got %p, %p in one scope and %p in another, want one instance per scope", a, b, c)
+	}
+
+	if _, err := Make[*tx](sc); !errors.Is(err, ErrScopeRequired) {
+		t.Fatalf("got error %v outside a scope, want ErrScopeRequired", err)
+	}
+	first.Close()
+	if _, err := Make[*tx](first); err == nil {
+		t.Fatal("resolved a scoped service from a closed scope")
+	}
+}
+
+// closeRecorder records the order it is closed in.
+type closeRecorder struct {
+	name   string
+	closed *[]string
+	err    error
+}
+
+func (r *closeRecorder) Close() error {
+	*r.closed = append(*r.closed, r.name)
+	return r.err
+}
+
+func TestShutdownDisposesSingletonsInReverseCreationOrder(t *testing.T) {
+	closed := []string{}
+	sc := NewServiceContainer()
+	sc.RegisterSingleton("db", func() interface{} {
+		return &closeRecorder{name: "db", closed: &closed, err: errors.New("busy")}
+	})
+	sc.RegisterSingleton("repo", func() interface{} {
+		sc.Resolve("db")
+		return &closeRecorder{name: "repo", closed: &closed}
+	})
+	sc.Register("transient", func() interface{} {
+		return &closeRecorder{name: "transient", closed: &closed}
+	}, false)
+	sc.Resolve("repo")
+	sc.Resolve("transient")
+
+	err := sc.Shutdown(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "busy") {
+		t.Fatalf("got error %v, want the error closing db", err)
+	}
+	if strings.Join(closed, ",") != "repo,db" {
+		t.Fatalf("closed %v, want repo before db and no transient", closed)
+	}
+}
+
+// auditLogger is a Logger telling consumers apart by its name.
+type auditLogger struct {
+	DefaultLogger
+	name string
+}
+
+func TestContextualBindingOverridesDependencyForOneConsumer(t *testing.T) {
+	type billing struct{ log Logger }
+	type orders struct{ log Logger }
+
+	sc := NewServiceContainer()
+	Singleton[*auditLogger](sc, func(c *ServiceContainer) (*auditLogger, error) {
+		return &auditLogger{name: "default"}, nil
+	})
+	if err := BindTo[Logger, *auditLogger](sc); err != nil {
+		t.Fatal(err)
+	}
+	if err := BindTo[Logger, *billing](sc); err == nil {
+		t.Fatal("bound Logger to a type not implementing it")
+	}
+	Bind[Logger](sc, func(c *ServiceContainer) (Logger, error) {
+		return &auditLogger{name: "audit"}, nil
+	}, Named("audit"))
+	WhenNeedsNamed[*billing, Logger](sc, "audit")
+	sc.Provide(func(log Logger) *billing { return &billing{log: log} })
+	sc.Provide(func(log Logger) *orders { return &orders{log: log} })
+
+	b, err := Make[*billing](sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := Make[*orders](sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := b.log.(*auditLogger).name; name != "audit" {
+		t.Fatalf("billing got the %s logger, want audit", name)
+	}
+	if name := o.log.(*auditLogger).name; name != "default" {
+		t.Fatalf("orders got the %s logger, want default", name)
+	}
+}
+
+func TestTaggedResolvesServicesInTaggingOrder(t *testing.T) {
+	sc := NewServiceContainer()
+	Bind[*closeRecorder](sc, func(c *ServiceContainer) (*closeRecorder, error) {
+		return &closeRecorder{name: "typed"}, nil
+	}, WithTags("exporter"))
+	sc.Register("csv", func() interface{} { return &closeRecorder{name: "csv"} }, false)
+	sc.Tag("csv", "exporter", "exporter")
+	sc.Register("count", func() interface{} { return 3 }, false)
+	sc.Tag("count", "numbers")
+
+	exporters, err := MakeTagged[*closeRecorder](sc, "exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exporters) != 2 || exporters[0].name != "typed" || exporters[1].name != "csv" {
+		t.Fatalf("got %v, want the typed exporter then csv", exporters)
+	}
+	if _, err := MakeTagged[*closeRecorder](sc, "numbers"); err == nil {
+		t.Fatal("resolved an int tagged service as a *closeRecorder")
+	}
+}
+
+func TestExtendersDecorateInOrderOncePerSingleton(t *testing.T) {
+	var created int32
+	sc := NewServiceContainer()
+	sc.Extend("greeting", func(service interface{}, c *ServiceContainer) interface{} {
+		return service.(string) + " world"
+	})
+	sc.RegisterSingleton("greeting", func() interface{} {
+		atomic.AddInt32(&created, 1)
+		return "hello"
+	})
+	sc.Extend("greeting", func(service interface{}, c *ServiceContainer) interface{} {
+		return service.(string) + "!"
+	})
+
+	for i := 0; i < 3; i++ {
+		if greeting, _ := sc.Resolve("greeting"); greeting != "hello world!" {
+			t.Fatalf("got %q, want extenders applied in order", greeting)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("factory called %d times, want 1", created)
+	}
+
+	Bind[int](sc, func(c *ServiceContainer) (int, error) { return 1, nil })
+	ExtendType[int](sc, func(n int, c *ServiceContainer) (int, error) {
+		return 0, errors.New("no cache")
+	})
+	if _, err := Make[int](sc); err == nil || !strings.Contains(err.Error(), "no cache") {
+		t.Fatalf("got error %v, want the extender's error", err)
+	}
+}
+
+func TestAutoResolveInjectsTaggedFields(t *testing.T) {
+	type repo struct{ id int }
+	type Base struct {
+		Log Logger `inject:""`
+	}
+	type controller struct {
+		*Base
+		Repo     *repo          `inject:""`
+		Greeting string         `inject:"greeting"`
+		Cache    *closeRecorder `inject:",optional"`
+		Nested   struct {
+			Repo *repo `inject:""`
+		}
+	}
+
+	sc := NewServiceContainer()
+	Singleton[*repo](sc, func(c *ServiceContainer) (*repo, error) { return &repo{id: 1}, nil })
+	Bind[Logger](sc, func(c *ServiceContainer) (Logger, error) { return &auditLogger{}, nil })
+	sc.Register("greeting", func() interface{} { return "hello" }, false)
+
+	target := &controller{Base: &Base{}}
+	if err := sc.AutoResolve(target); err != nil {
+		t.Fatal(err)
+	}
+	if target.Repo == nil || target.Nested.Repo != target.Repo || target.Log == nil || target.Greeting != "hello" || target.Cache != nil {
+		t.Fatalf("got %+v, want every field but the optional one injected", target)
+	}
+
+	var invalid struct {
+		Greeting int            `inject:"greeting"`
+		Missing  *closeRecorder `inject:""`
+	}
+	err := sc.AutoResolve(&invalid)
+	if err == nil || !strings.Contains(err.Error(), "Greeting") || !strings.Contains(err.Error(), "Missing") {
+		t.Fatalf("got error %v, want both fields reported", err)
+	}
+}
+
+func TestDescribeReportsServicesAndConstructorDependencies(t *testing.T) {
+	type repo struct{ id int }
+	sc := NewServiceContainer()
+	sc.ProvideSingleton(func(log Logger, c *ServiceContainer) *repo { return &repo{} }, WithTags("storage"))
+	sc.Extend("config", func(service interface{}, c *ServiceContainer) interface{} { return service })
+	sc.RegisterSingleton("config", func() interface{} { return 1 })
+	sc.Resolve("config")
+
+	descriptions := sc.Describe()
+	if len(descriptions) != 2 {
+		t.Fatalf("got %d services, want 2", len(descriptions))
+	}
+	r, config := descriptions[0], descriptions[1]
+	if r.Service != "*core.repo" || r.Instantiated || strings.Join(r.Tags, ",") != "storage" || strings.Join(r.Dependencies, ",") != "core.Logger" {
+		t.Fatalf("got %+v for the repo", r)
+	}
+	if config.Service != "config" || !config.Instantiated || config.Extenders != 1 || config.Lifetime != LifetimeSingleton {
+		t.Fatalf("got %+v for config", config)
+	}
+
+	var graph strings.Builder
+	if err := sc.WriteGraph(&graph); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(graph.String(), `"*core.repo" -> "core.Logger";`) || !strings.Contains(graph.String(), `"core.Logger" [style=dashed];`) {
+		t.Fatalf("graph is missing the dependency on the unregistered logger:\n%s", graph.String())
+	}
+}
+
+func TestShutdownSkipsUnhashableValues(t *testing.T) {
+	type holder struct{ v []int }
+	sc := NewServiceContainer()
+	sc.RegisterSingleton("h", func() interface{} { return holder{v: []int{1}} })
+	sc.Resolve("h")
+	if err := sc.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShutdownLeavesInstancesOwnedByTheCaller(t *testing.T) {
+	closed := []string{}
+	sc := NewServiceContainer()
+	sc.Instance("log", &closeRecorder{name: "log", closed: &closed})
+	sc.Resolve("log")
+	if err := sc.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(closed) != 0 {
+		t.Fatalf("closed %v, want the caller's instance left open", closed)
+	}
+}
+
+func TestCycleThroughFactoriesIsReported(t *testing.T) {
+	type a struct{}
+	type b struct{}
+	sc := NewServiceContainer()
+	sc.Provide(func(*b) *a { return &a{} })
+	Singleton[*b](sc, func(c *ServiceContainer) (*b, error) {
+		_, err := Make[*a](c)
+		return &b{}, err
+	})
+
+	_, err := Make[*a](sc)
+	var cycle *CycleError
+	if !errors.As(err, &cycle) || err.Error() != "dependency cycle: *core.a -> *core.b -> *core.a" {
+		t.Fatalf("got error %v, want the cycle", err)
+	}
+}
+
+func TestCycleThroughRegisterClosuresIsReported(t *testing.T) {
+	for _, singleton := range []bool{false, true} {
+		sc := NewServiceContainer()
+		sc.Register("a", func() interface{} { service, _ := sc.Resolve("b"); return service }, singleton)
+		sc.Register("b", func() interface{} { service, _ := sc.Resolve("a"); return service }, singleton)
+
+		_, err := sc.Resolve("a")
+		var cycle *CycleError
+		if !errors.As(err, &cycle) || err.Error() != "dependency cycle: a -> b -> a" {
+			t.Fatalf("resolving singleton=%v services needing each other through closures returned %v, want the cycle", singleton, err)
+		}
+	}
+}
+
+func TestConcurrentRegisterClosuresAreNotCycles(t *testing.T) {
+	sc := NewServiceContainer()
+	sc.Register("config", func() interface{} {
+		time.Sleep(10 * time.Millisecond)
+		return "config"
+	}, true)
+	sc.Register("client", func() interface{} {
+		config, _ := sc.Resolve("config")
+		return config
+	}, false)
+
+	for _, service := range resolveConcurrently(t, 50, func() (interface{}, error) { return sc.Resolve("client") }) {
+		if service != "config" {
+			t.Fatalf("got %v, want the shared config", service)
+		}
+	}
+}
+
+func TestContextualBindingOverridesCachedSingleton(t *testing.T) {
+	type billing struct{ name string }
+	sc := NewServiceContainer()
+	Singleton[string](sc, func(c *ServiceContainer) (string, error) { return "default", nil })
+	WhenNeeds[*billing, string](sc, func(c *ServiceContainer) (string, error) { return "audit", nil })
+	sc.Provide(func(name string) *billing { return &billing{name: name} })
+
+	if name, _ := Make[string](sc); name != "default" {
+		t.Fatalf("got %q, want the default outside billing", name)
+	}
+	if b, _ := Make[*billing](sc); b == nil || b.name != "audit" {
+		t.Fatalf("got %+v, want billing to get the override of the cached singleton", b)
+	}
+}
+
+func TestVerifyReportsWiringWithoutCreatingTransients(t *testing.T) {
+	type repo struct{ id int }
+	type mailer struct{ id int }
+	var created int32
+	sc := NewServiceContainer()
+	sc.Provide(func(m *mailer) *repo {
+		atomic.AddInt32(&created, 1)
+		return &repo{}
+	})
+	Singleton[int](sc, func(c *ServiceContainer) (int, error) { return 0, errors.New("no database") })
+
+	err := sc.Verify()
+	if err == nil || !strings.Contains(err.Error(), "Service '*core.mailer' not registered (required by *core.repo)") ||
+		!strings.Contains(err.Error(), "no database") {
+		t.Fatalf("got error %v, want the missing mailer and the failing singleton", err)
+	}
+	if created != 0 {
+		t.Fatalf("Verify created %d transient instances, want none", created)
+	}
+}
+
+func TestProvideAndCallPropagateErrors(t *testing.T) {
+	type repo struct{ name string }
+	sc := NewServiceContainer()
+	if err := sc.Provide(func() (*repo, error) { return nil, errors.New("connection refused") }); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.Provide(func() {}); err == nil {
+		t.Fatal("accepted a constructor returning nothing")
+	}
+
+	_, err := sc.Call(func(r *repo) {})
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("got error %v, want the constructor's error", err)
+	}
+
+	sc.Provide(func() *repo { return &repo{name: "users"} })
+	results, err := sc.Call(func(prefix string, r *repo) (string, error) { return prefix + r.name, nil }, "all ")
+	if err != nil || results[0] != "all users" {
+		t.Fatalf("got %v, %v, want the argument and the service", results, err)
+	}
+	if _, err := sc.Call(func(r *repo) {}, 42); err == nil {
+		t.Fatal("accepted an argument matching no parameter")
+	}
+	if _, err := sc.Call(func() error { return errors.New("failed") }); err == nil || err.Error() != "failed" {
+		t.Fatalf("got error %v, want the function's error", err)
+	}
+}
+
+// Node embeds a pointer to its own type.
+type Node struct {
+	*Node
+	Greeting string `inject:"greeting"`
+}
+
+func TestAutoResolveFollowsSelfReferentialEmbeddedPointersOnce(t *testing.T) {
+	sc := NewServiceContainer()
+	sc.Register("greeting", func() interface{} { return "hello" }, false)
+	node := &Node{}
+	node.Node = node
+	if err := sc.AutoResolve(node); err != nil || node.Greeting != "hello" {
+		t.Fatalf("got %q, %v, want the field injected", node.Greeting, err)
+	}
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ServiceDescription describes a service registered in the container.
+type ServiceDescription struct {
+	Service      string   // Name of a string service, or type and name of a typed binding
+	Name         string   // Name of the service, empty for typed bindings without Named
+	Type         string   // Type of a typed binding, empty for string services
+	Lifetime     Lifetime // How long an instance is reused
+	Tags         []string
+	Extenders    int      // Number of extenders decorating the service
+	Instantiated bool     // A singleton, or a scoped service of this scope, has been created
+	Dependencies []string // Services the constructor asks for (see Provide), or a binding delegates to
+}
+
+// Describe describes the registered services, sorted by Service.
+func (sc *ServiceContainer) Describe() []ServiceDescription {
+	sc.lock.RLock()
+	defer sc.lock.RUnlock()
+
+	tags := map[serviceKey][]string{}
+	for tag, keys := range sc.tags {
+		for _, key := range keys {
+			tags[key] = append(tags[key], tag)
+		}
+	}
+
+	descriptions := make([]ServiceDescription, 0, len(sc.factories))
+	for key := range sc.factories {
+		description := ServiceDescription{
+			Service:      key.String(),
+			Name:         key.name,
+			Lifetime:     sc.lifetimes[key],
+			Tags:         tags[key],
+			Extenders:    len(sc.extenders[key]),
+			Dependencies: keyNames(sc.dependencies[key]),
+		}
+		if key.typ != nil {
+			description.Type = key.typ.String()
+		}
+		sort.Strings(description.Tags)
+		if cache, _ := sc.cacheFor(key, description.Lifetime); cache != nil {
+			_, description.Instantiated = cache.services[key]
+		}
+		descriptions = append(descriptions, description)
+	}
+	sort.Slice(descriptions, func(i, j int) bool {
+		return descriptions[i].Service < descriptions[j].Service
+	})
+	return descriptions
+}
+
+// WriteGraph writes the services and the dependencies Describe reports as a Graphviz DOT
+// graph, e.g. for `dot -Tsvg`. Dependencies that are not registered are dashed.
+func (sc *ServiceContainer) WriteGraph(w io.Writer) error {
+	descriptions := sc.Describe()
+	registered := map[string]bool{}
+	for _, description := range descriptions {
+		registered[description.Service] = true
+	}
+
+	lines := []string{"digraph services {", "\trankdir=LR;", "\tnode [shape=box];"}
+	missing := map[string]bool{}
+	for _, description := range descriptions {
+		lines = append(lines, fmt.Sprintf("\t%q [label=%q];", description.Service, description.Service+"\n"+description.Lifetime.String()))
+		for _, dependency := range description.Dependencies {
+			if !registered[dependency] && !missing[dependency] {
+				missing[dependency] = true
+				lines = append(lines, fmt.Sprintf("\t%q [style=dashed];", dependency))
+			}
+			lines = append(lines, fmt.Sprintf("\t%q -> %q;", description.Service, dependency))
+		}
+	}
+	lines = append(lines, "}")
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// shutdowner is implemented by services that stop gracefully, like *http.Server.
+type shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// Shutdown disposes the singletons the container created, in reverse creation order so
+// services are disposed before their dependencies: it calls Shutdown(ctx) on those
+// implementing it and Close() on the others implementing io.Closer, and returns all their
+// errors. Singletons resolved afterwards are created again. Transient instances belong to
+// whoever resolved them and are never disposed by the container.
+func (sc *ServiceContainer) Shutdown(ctx context.Context) error {
+	sc.lock.Lock()
+	created := sc.instances.created
+	sc.instances = newInstanceCache()
+	sc.lock.Unlock()
+
+	return dispose(ctx, created)
+}
+
+// dispose shuts down or closes instances in reverse order, once each.
+func dispose(ctx context.Context, instances []interface{}) error {
+	errs := []error{}
+	disposed := map[instanceIdentity]bool{}
+	for i := len(instances) - 1; i >= 0; i-- {
+		instance := instances[i]
+		if instance == nil {
+			continue
+		}
+		// The same instance may be cached under several keys
+		if identity, ok := identify(instance); ok {
+			if disposed[identity] {
+				continue
+			}
+			disposed[identity] = true
+		}
+
+		var err error
+		switch service := instance.(type) {
+		case shutdowner:
+			err = service.Shutdown(ctx)
+		case io.Closer:
+			err = service.Close()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("disposing %T: %w", instance, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// instanceIdentity identifies an instance that refers to shared state, like a pointer.
+type instanceIdentity struct {
+	typ     reflect.Type
+	pointer uintptr
+}
+
+// identify returns the identity of pointer-like instances. Other values are copies, which
+// can't be shared between keys, and may not even be hashable.
+func identify(instance interface{}) (instanceIdentity, bool) {
+	value := reflect.ValueOf(instance)
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return instanceIdentity{typ: value.Type(), pointer: value.Pointer()}, true
+	}
+	return instanceIdentity{}, false
+}
@@ -0,0 +1,68 @@
+package core
+
+import "fmt"
+
+// extender decorates an instance of a service after its factory created it.
+type extender func(service interface{}, c *ServiceContainer) (interface{}, error)
+
+// Extend wraps the service registered under a name without replacing its factory, e.g. with
+// a sampling or caching decorator:
+//
+//	services.Extend("logger", func(service interface{}, c *core.ServiceContainer) interface{} {
+//		return NewSamplingLogger(service.(core.Logger), 0.1)
+//	})
+//
+// Extenders run in the order they were added, each receiving the result of the previous one,
+// when an instance is created: once for singletons, once per scope for scoped services and on
+// every resolution otherwise. A singleton already created is created again on its next
+// resolution. Service providers extend services in Register, whether or not they are
+// registered yet.
+func (sc *ServiceContainer) Extend(name string, fn func(service interface{}, c *ServiceContainer) interface{}) {
+	sc.extend(serviceKey{name: name}, func(service interface{}, c *ServiceContainer) (interface{}, error) {
+		return fn(service, c), nil
+	})
+}
+
+// ExtendType wraps the service bound to the type T like Extend; the decorator may fail:
+//
+//	core.ExtendType[*UserRepo](services, func(repo *UserRepo, c *core.ServiceContainer) (*UserRepo, error) {
+//		return repo.WithCache(cache), nil
+//	})
+//
+// Pass Named(name) to wrap a named binding of T.
+func ExtendType[T any](sc *ServiceContainer, fn func(service T, c *ServiceContainer) (T, error), options ...BindOption) {
+	settings := newBindOptions(options)
+	key := serviceKey{typ: typeOf[T](), name: settings.name}
+	sc.extend(key, func(service interface{}, c *ServiceContainer) (interface{}, error) {
+		typed, ok := service.(T)
+		if !ok && service != nil {
+			return nil, fmt.Errorf("service is a %T, not a %s", service, key.typ)
+		}
+		return fn(typed, c)
+	})
+}
+
+// extend adds an extender to a service, dropping the singleton instance created without it.
+func (sc *ServiceContainer) extend(key serviceKey, fn extender) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	sc.extenders[key] = append(sc.extenders[key], fn)
+	delete(sc.instances.services, key)
+	delete(sc.instances.creating, key)
+}
+
+// decorate applies the extenders of a service to an instance its factory created.
+func (sc *ServiceContainer) decorate(key serviceKey, service interface{}, c *ServiceContainer) (interface{}, error) {
+	sc.lock.RLock()
+	extenders := sc.extenders[key]
+	sc.lock.RUnlock()
+
+	for i, extend := range extenders {
+		var err error
+		if service, err = extend(service, c); err != nil {
+			return nil, fmt.Errorf("extender %d: %w", i+1, err)
+		}
+	}
+	return service, nil
+}
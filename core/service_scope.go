@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrScopeRequired is returned when a scoped service is resolved outside a scope.
+var ErrScopeRequired = errors.New("is scoped and must be resolved from a scope")
+
+// serviceScope holds the instances of scoped services of one scope, e.g. one request.
+type serviceScope struct {
+	instances *instanceCache // nil once the scope is closed
+}
+
+// Scoped registers a factory for the type T whose instance is created once per scope, e.g.
+// the current user or a database transaction of a request.
+func Scoped[T any](sc *ServiceContainer, factory func(c *ServiceContainer) (T, error), options ...BindOption) {
+	bindTyped(sc, factory, LifetimeScoped, options)
+}
+
+// ProvideScoped registers a constructor like Provide whose instance is created once per scope.
+func (sc *ServiceContainer) ProvideScoped(constructor interface{}, options ...BindOption) error {
+	return sc.provide(constructor, LifetimeScoped, options)
+}
+
+// NewScope creates a scope resolving services from this container, with its own instances
+// of scoped services. Close it when it ends.
+func (sc *ServiceContainer) NewScope() *ServiceContainer {
+	return &ServiceContainer{containerState: sc.containerState, scope: &serviceScope{instances: newInstanceCache()}}
+}
+
+// IsScope reports whether the container is a scope created with NewScope.
+func (sc *ServiceContainer) IsScope() bool {
+	return sc.scope != nil
+}
+
+// Close ends a scope, disposing its scoped instances like Shutdown; resolving scoped
+// services from it fails afterwards. It has no effect on a container that is not a scope.
+func (sc *ServiceContainer) Close() error {
+	if sc.scope == nil {
+		return nil
+	}
+	sc.lock.Lock()
+	instances := sc.scope.instances
+	sc.scope.instances = nil
+	sc.lock.Unlock()
+
+	if instances == nil {
+		return nil
+	}
+	return dispose(context.Background(), instances.created)
+}
+
+// PutScoped stores a value of the type T in a scope, where it is resolved like a scoped
+// service, e.g. the user authenticated by a middleware.
+func PutScoped[T any](scope *ServiceContainer, value T, options ...BindOption) error {
+	if scope.scope == nil {
+		return errors.New("PutScoped needs a scope created with NewScope")
+	}
+	settings := newBindOptions(options)
+
+	scope.lock.Lock()
+	defer scope.lock.Unlock()
+	if scope.scope.instances == nil {
+		return errors.New("the scope is closed")
+	}
+	scope.scope.instances.services[serviceKey{typ: typeOf[T](), name: settings.name}] = value
+	return nil
+}
+
+// scopeContextKey is the context key of the request scope.
+type scopeContextKey struct{}
+
+// WithScope returns a context carrying the scope.
+func WithScope(ctx context.Context, scope *ServiceContainer) context.Context {
+	return context.WithValue(ctx, scopeContextKey{}, scope)
+}
+
+// ScopeFromContext returns the scope carried by the context, or nil.
+func ScopeFromContext(ctx context.Context) *ServiceContainer {
+	scope, _ := ctx.Value(scopeContextKey{}).(*ServiceContainer)
+	return scope
+}
+
+// RequestScope returns the scope of a request handled by the kernel, or nil:
+//
+//	tx, err := core.Make[*sql.Tx](core.RequestScope(r))
+func RequestScope(r *http.Request) *ServiceContainer {
+	return ScopeFromContext(r.Context())
+}
+
+// ScopeMiddleware creates a scope of the service container for each request, in which the
+// request is resolvable as *http.Request, and closes it when the response completes.
+// HandleRequest applies it to every request; add it to the middleware of listeners with
+// their own handler.
+func (k *Kernel) ScopeMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if RequestScope(r) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scope := k.Services.NewScope()
+			defer scope.Close()
+			r = r.WithContext(WithScope(r.Context(), scope))
+			PutScoped(scope, r)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
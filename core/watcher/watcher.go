@@ -0,0 +1,125 @@
+// Package watcher polls files and directories for changes.
+package watcher
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// fileState is the part of a file's metadata compared between polls.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher polls a set of files and directories and reports changed paths.
+type Watcher struct {
+	Roots      []string      // Files or directories to watch
+	Extensions []string      // File extensions to watch, e.g. ".go"; empty watches every file
+	Ignore     []string      // Directory names skipped while walking, e.g. ".git"
+	Interval   time.Duration // Time between polls
+
+	snapshot map[string]fileState
+}
+
+// New creates a watcher polling the given roots every interval.
+func New(interval time.Duration, roots ...string) *Watcher {
+	return &Watcher{
+		Roots:    roots,
+		Ignore:   []string{".git", ".idea", "node_modules", "storage", "vendor"},
+		Interval: interval,
+	}
+}
+
+// Run polls until stop is closed, calling onChange with the paths created, modified or removed since the last poll.
+func (w *Watcher) Run(stop <-chan struct{}, onChange func(changed []string)) {
+	w.snapshot = w.scan()
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if changed := w.Poll(); len(changed) > 0 {
+				onChange(changed)
+			}
+		}
+	}
+}
+
+// Poll scans the roots once and returns the paths changed since the previous scan.
+func (w *Watcher) Poll() []string {
+	current := w.scan()
+	changed := []string{}
+	if w.snapshot != nil {
+		for path, state := range current {
+			if previous, ok := w.snapshot[path]; !ok || previous != state {
+				changed = append(changed, path)
+			}
+		}
+		for path := range w.snapshot {
+			if _, ok := current[path]; !ok {
+				changed = append(changed, path)
+			}
+		}
+	}
+	w.snapshot = current
+	sort.Strings(changed)
+	return changed
+}
+
+// scan collects the state of every watched file under the roots.
+func (w *Watcher) scan() map[string]fileState {
+	states := make(map[string]fileState)
+	for _, root := range w.Roots {
+		filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entry.IsDir() {
+				if path != root && w.ignored(entry.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !w.watched(path) {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return nil
+			}
+			states[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+			return nil
+		})
+	}
+	return states
+}
+
+// ignored reports whether a directory is skipped.
+func (w *Watcher) ignored(name string) bool {
+	for _, ignore := range w.Ignore {
+		if name == ignore {
+			return true
+		}
+	}
+	return false
+}
+
+// watched reports whether a file has one of the watched extensions.
+func (w *Watcher) watched(path string) bool {
+	if len(w.Extensions) == 0 {
+		return true
+	}
+	ext := filepath.Ext(path)
+	for _, watched := range w.Extensions {
+		if ext == watched {
+			return true
+		}
+	}
+	return false
+}
@@ -1,25 +1,12 @@
 package main
 
 import (
-	"icepeak/core"
-	"icepeak/routes"
+	"icepeak/bootstrap"
 )
 
 func main() {
-	// Create a new kernel instance
-	kernel := core.NewKernel()
-
-	// Register middleware globally
-	kernel.RegisterMiddleware(core.RequestLoggingMiddleware)
-	kernel.RegisterMiddleware(core.CORSMiddleware(core.CORSOptions{
-		AllowedOrigins: []string{"*"},
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Content-Type", "Authorization"},
-	}))
-
-	// Register routes with selective middleware
-	routes.RegisterWebRoutes(kernel.Router, kernel.Config["VIEW_ROOT"].(string), core.InputValidationMiddleware([]string{"name", "email"}))
-	routes.RegisterAPIRoutes(kernel.Router)
+	// Create the application kernel with its middleware and routes
+	kernel := bootstrap.NewKernel()
 
 	// Start the server
 	kernel.StartServer(":8080")
@@ -7,8 +7,11 @@ import (
 
 // RegisterAPIRoutes registers API routes
 func RegisterAPIRoutes(router *routing.Router) {
+	// API routes use the "api" middleware group
+	api := router.Group("/api").Use("api")
+
 	// Example of registering a GET route using the router instance
-	router.Get("/api/users", controllers.UserController)
+	api.Get("/users", controllers.UserController)
 
 	// Add more API routes here as needed...
 }
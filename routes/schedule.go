@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"icepeak/core/schedule"
+)
+
+// RegisterSchedule registers the application's scheduled tasks
+func RegisterSchedule(scheduler *schedule.Scheduler) {
+	// Add scheduled tasks here, e.g.:
+	// scheduler.Call("reports:send", reports.Send).DailyAt("03:00").WithoutOverlapping()
+	// scheduler.Exec("backups:run", "./scripts/backup.sh").Hourly().OnOneServer()
+}
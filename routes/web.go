@@ -8,9 +8,12 @@ import (
 
 // RegisterWebRoutes registers web routes
 func RegisterWebRoutes(router *routing.Router, viewRoot string, validationMiddleware func(http.Handler) http.Handler) {
+	// Web routes use the "web" middleware group
+	web := router.Group("").Use("web")
+
 	// Home route - No input validation middleware required here
-	router.Get("/", controllers.HomeController(viewRoot))
+	web.Get("/", controllers.HomeController(viewRoot))
 
 	// Example of applying InputValidationMiddleware to a specific route
-	router.Get("/submit", controllers.HomeController(viewRoot), validationMiddleware)
+	web.Get("/submit", controllers.HomeController(viewRoot), validationMiddleware)
 }